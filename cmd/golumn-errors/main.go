@@ -0,0 +1,33 @@
+// Command golumn-errors exports the catalogue of application
+// error codes as json, for client SDKs to map errorCode to name.
+//
+//	go run ./cmd/golumn-errors -o docs/error_codes.json
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"github.com/iqdf/golumn-story-service/domain"
+)
+
+func main() {
+	output := flag.String("o", "", "write catalogue to file instead of stdout")
+	flag.Parse()
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := domain.WriteErrorCatalogue(w); err != nil {
+		log.Fatal(err)
+	}
+}
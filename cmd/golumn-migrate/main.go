@@ -0,0 +1,91 @@
+// Command golumn-migrate applies, rolls back and lists the
+// versioned schema migrations of the database.
+//
+//	go run ./cmd/golumn-migrate -dialect mysql -dsn "user:pass@tcp(localhost:3306)/golumn?parseTime=true" up
+//	go run ./cmd/golumn-migrate -dialect postgres -dsn "postgres://localhost/golumn" status
+//	go run ./cmd/golumn-migrate -dsn "..." -steps 2 down
+//
+// MySQL dsn must set parseTime=true.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+
+	"github.com/iqdf/golumn-story-service/lib/migrations"
+)
+
+func main() {
+	dialect := flag.String("dialect", "mysql", "database dialect, mysql or postgres")
+	dsn := flag.String("dsn", os.Getenv("GOLUMN_DSN"), "database dsn, defaults to GOLUMN_DSN env")
+	steps := flag.Int("steps", 1, "number of migrations rolled back by down")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] up|down|status\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *dsn == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db, err := gorm.Open(*dialect, *dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch flag.Arg(0) {
+	case "up":
+		done, err := migrator.Up()
+		printMigrations("applied", done)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "down":
+		done, err := migrator.Down(*steps)
+		printMigrations("rolled back", done)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal(err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		w.Flush()
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func printMigrations(action string, done []migrations.Migration) {
+	for _, migration := range done {
+		fmt.Printf("%s %04d_%s\n", action, migration.Version, migration.Name)
+	}
+	if len(done) == 0 {
+		fmt.Printf("nothing %s\n", action)
+	}
+}
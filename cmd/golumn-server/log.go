@@ -0,0 +1,58 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/iqdf/golumn-story-service/cmd/internal/app"
+	"github.com/iqdf/golumn-story-service/lib/middleware"
+)
+
+// Loggers of each log level, those below the configured
+// level discard their output
+type Loggers struct {
+	Debug *log.Logger
+	Info  *log.Logger
+	Error *log.Logger
+}
+
+// NewLoggers creates Loggers writing to w from given level
+func NewLoggers(w io.Writer, level string) Loggers {
+	writer := func(enabled bool) io.Writer {
+		if enabled {
+			return w
+		}
+		return ioutil.Discard
+	}
+	return Loggers{
+		Debug: log.New(writer(level == app.LogLevelDebug), "level=debug ", log.LstdFlags),
+		Info:  log.New(writer(level != app.LogLevelError), "level=info ", log.LstdFlags),
+		Error: log.New(w, "level=error ", log.LstdFlags),
+	}
+}
+
+// statusWriter records status code written to the response
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests logs every request with its status and duration,
+// it must be wrapped by middleware.RequestID
+func logRequests(logger *log.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		logger.Printf("request_id=%s method=%s path=%q status=%d duration=%s",
+			middleware.RequestIDFromContext(r.Context()), r.Method, r.URL.Path, sw.status, time.Since(start))
+	})
+}
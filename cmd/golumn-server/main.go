@@ -0,0 +1,46 @@
+// Command golumn-server runs the golumn http server.
+//
+//	golumn-server -config /etc/golumn/config.json
+//	GOLUMN_DSN="user:pass@tcp(localhost:3306)/golumn?parseTime=true" \
+//	GOLUMN_PUBLIC_ID_SALT=secret golumn-server -listen-addr :8080 -migrate
+//
+// Options are read from json config file, then GOLUMN_* env vars,
+// then flags; run with -h to list them. The server shuts down
+// gracefully on SIGTERM or SIGINT.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/iqdf/golumn-story-service/cmd/internal/app"
+)
+
+func main() {
+	fs := flag.NewFlagSet("golumn-server", flag.ContinueOnError)
+	cfg, err := app.LoadConfig(fs, os.Args[1:], os.Getenv)
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	loggers := NewLoggers(os.Stderr, cfg.LogLevel)
+	if err := Run(ctx, cfg, loggers); err != nil {
+		loggers.Error.Fatal(err)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/iqdf/golumn-story-service/cmd/internal/app"
+	"github.com/iqdf/golumn-story-service/lib/middleware"
+	"github.com/iqdf/golumn-story-service/lib/migrations"
+	userhttp "github.com/iqdf/golumn-story-service/user/delivery/http"
+	"github.com/iqdf/golumn-story-service/user/service"
+)
+
+// Run serves http until ctx is done, then shuts down gracefully,
+// waiting for running requests up to the shutdown timeout
+func Run(ctx context.Context, cfg app.Config, loggers Loggers) error {
+	db, err := app.OpenDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if cfg.Migrate {
+		if err := migrate(db, loggers); err != nil {
+			return err
+		}
+	}
+
+	handler, purger, err := newServer(db, cfg, loggers)
+	if err != nil {
+		return err
+	}
+
+	purgerCtx, stopPurger := context.WithCancel(context.Background())
+	purgerDone := make(chan struct{})
+	go func() {
+		purger.Run(purgerCtx, time.Duration(cfg.PurgeInterval))
+		close(purgerDone)
+	}()
+	defer func() {
+		stopPurger()
+		<-purgerDone
+	}()
+
+	server := &http.Server{
+		Addr:              cfg.ListenAddr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ErrorLog:          loggers.Error,
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		loggers.Info.Printf("server: listening on %s", cfg.ListenAddr)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	loggers.Info.Printf("server: shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout))
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	loggers.Info.Printf("server: stopped")
+	return nil
+}
+
+// newServer wires services and handlers on db
+func newServer(db *gorm.DB, cfg app.Config, loggers Loggers) (http.Handler, *service.UserPurger, error) {
+	services, err := app.NewServices(db, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	purger := service.NewUserPurger(services.Repos.Users, services.UoW, time.Duration(cfg.GracePeriod), loggers.Info)
+
+	mux := http.NewServeMux()
+	renderer := middleware.NewErrorRenderer(loggers.Error, cfg.Debug)
+	// owner routes, i.e. every /users/{id} route, stay off
+	// as no authentication middleware sets the principal yet
+	userHandler := userhttp.NewUserHandlerWithErrorRenderer(mux, services.Users, renderer)
+	userHandler.OwnerRoutes = false
+
+	var handler http.Handler = mux
+	if cfg.LogLevel == app.LogLevelDebug {
+		handler = logRequests(loggers.Debug, handler)
+	}
+	return middleware.RequestID(handler), purger, nil
+}
+
+func migrate(db *gorm.DB, loggers Loggers) error {
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	done, err := migrator.Up()
+	for _, migration := range done {
+		loggers.Info.Printf("server: applied migration %04d_%s", migration.Version, migration.Name)
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iqdf/golumn-story-service/cmd/internal/app"
+	"github.com/iqdf/golumn-story-service/lib/middleware"
+	"github.com/iqdf/golumn-story-service/lib/publicid"
+)
+
+func TestNewServerHandlesRequests(t *testing.T) {
+	sqlDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := gorm.Open("mysql", sqlDB)
+	require.NoError(t, err)
+	defer publicid.SetDefault(publicid.NewEncoder(publicid.DefaultSalt))
+
+	var logs bytes.Buffer
+	cfg := app.DefaultConfig()
+	cfg.LogLevel, cfg.PublicIDSalt = app.LogLevelDebug, "secret"
+	handler, purger, err := newServer(db, cfg, NewLoggers(&logs, cfg.LogLevel))
+	require.NoError(t, err)
+	require.NotNil(t, purger)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("{")))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	id := publicid.Encode(1)
+	for _, target := range []string{"/users/" + id, "/users/" + id + "/restore", "/users/" + id + "/follow/alice"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, nil))
+		require.Equal(t, http.StatusNotFound, w.Code, "owner routes must not be served")
+	}
+
+	requestID := w.Header().Get(middleware.RequestIDHeader)
+	require.NotEmpty(t, requestID)
+	require.Contains(t, logs.String(), "request_id="+requestID)
+	require.Contains(t, logs.String(), "status=400")
+}
+
+func TestLoggersDiscardBelowLevel(t *testing.T) {
+	var logs bytes.Buffer
+	loggers := NewLoggers(&logs, app.LogLevelError)
+	loggers.Debug.Print("debug")
+	loggers.Info.Print("info")
+	require.Zero(t, logs.Len())
+
+	loggers.Error.Print("error")
+	require.True(t, strings.HasPrefix(logs.String(), "level=error "), logs.String())
+}
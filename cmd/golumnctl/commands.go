@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/lib/publicid"
+)
+
+// command of golumnctl, run with args following its name
+type command struct {
+	usage string
+	run   func(c *ctl, ctx context.Context, args []string) error
+}
+
+// commands by name, set by init as they refer to commands in usage errors
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"get":       {"get <user>", (*ctl).get},
+		"create":    {"create -email EMAIL -username USERNAME -name NAME [-location L] [-description D]", (*ctl).create},
+		"delete":    {"delete <user>", (*ctl).delete},
+		"restore":   {"restore <id>", (*ctl).restore},
+		"rename":    {"rename <user> <username>", (*ctl).rename},
+		"followers": {"followers [-page N] [-limit N] <user>", (*ctl).followers},
+		"import":    {"import [-format csv|jsonl] <file|->", (*ctl).importUsers},
+	}
+}
+
+func commandUsage() string {
+	usages := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		usages = append(usages, "  "+cmd.usage+"\n")
+	}
+	sort.Strings(usages)
+	return strings.Join(usages, "")
+}
+
+// ctl runs commands on users through the user service,
+// and through the repository where the service has no use-case
+type ctl struct {
+	users   domain.UserRepository
+	service domain.UserService
+	printer *printer
+}
+
+func newCtl(users domain.UserRepository, service domain.UserService, printer *printer) *ctl {
+	return &ctl{users: users, service: service, printer: printer}
+}
+
+func (c *ctl) run(ctx context.Context, args []string) error {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q, commands are:\n%s", args[0], commandUsage())
+	}
+	return cmd.run(c, ctx, args[1:])
+}
+
+// resolve finds user by public id, @username or email
+func (c *ctl) resolve(ctx context.Context, ref string) (domain.User, error) {
+	switch {
+	case strings.HasPrefix(ref, "@"):
+		return c.users.GetByUsernameContext(ctx, strings.TrimPrefix(ref, "@"))
+	case strings.Contains(ref, "@"):
+		return c.users.GetByEmailContext(ctx, ref)
+	}
+	userID, err := publicid.Decode(ref)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("%q is neither public id, @username nor email", ref)
+	}
+	return c.users.GetByIDContext(ctx, userID)
+}
+
+func (c *ctl) get(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return usageError("get")
+	}
+	user, err := c.resolve(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return c.printer.User(user)
+}
+
+func (c *ctl) create(ctx context.Context, args []string) error {
+	var user domain.User
+	fs := newFlagSet("create")
+	email := fs.String("email", "", "email of user")
+	fs.StringVar(&user.Username, "username", "", "username of user")
+	fs.StringVar(&user.Name, "name", "", "name of user")
+	fs.StringVar(&user.Location, "location", "", "location of user")
+	fs.StringVar(&user.Description, "description", "", "description of user")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return usageError("create")
+	}
+
+	user.Email = *email
+	created, err := c.service.CreateUserContext(ctx, user)
+	if err != nil {
+		return err
+	}
+	return c.printer.User(created)
+}
+
+func (c *ctl) delete(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return usageError("delete")
+	}
+	user, err := c.resolve(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if err := c.service.DeleteUserContext(ctx, user.ID); err != nil {
+		return err
+	}
+	return c.printer.User(user)
+}
+
+func (c *ctl) restore(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return usageError("restore")
+	}
+	userID, err := publicid.Decode(args[0])
+	if err != nil {
+		return fmt.Errorf("deleted user can only be restored by public id, got %q", args[0])
+	}
+	user, err := c.service.RestoreUserContext(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return c.printer.User(user)
+}
+
+func (c *ctl) rename(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return usageError("rename")
+	}
+	user, err := c.resolve(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	renamed, err := c.service.UpdateUsernameContext(ctx, user.ID, domain.User{Username: args[1]})
+	if err != nil {
+		return err
+	}
+	return c.printer.User(renamed)
+}
+
+func (c *ctl) followers(ctx context.Context, args []string) error {
+	fs := newFlagSet("followers")
+	page := fs.Int("page", 1, "page of followers")
+	limit := fs.Int("limit", 20, "number of followers per page")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return usageError("followers")
+	}
+
+	user, err := c.resolve(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	followers, err := c.users.ListFollowersContext(ctx, user.ID, *page, *limit)
+	if err != nil {
+		return err
+	}
+	return c.printer.Users(followers)
+}
+
+// newFlagSet creates flag set of command, whose errors
+// are reported by usageError instead
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	return fs
+}
+
+func usageError(name string) error {
+	return fmt.Errorf("usage: golumnctl %s", commands[name].usage)
+}
+
+// errorMessage formats err with details of invalid fields, if any
+func errorMessage(err error) string {
+	message := err.Error()
+	var appErr *domain.AppError
+	if errors.As(err, &appErr) {
+		for _, detail := range appErr.Details {
+			message += fmt.Sprintf("\n  %s: %s", detail.Field, detail.Message)
+		}
+	}
+	return message
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/lib/publicid"
+	"github.com/iqdf/golumn-story-service/user/repository/memory"
+	"github.com/iqdf/golumn-story-service/user/service"
+)
+
+// newTestCtl creates ctl on memory repository with alice followed by bob
+func newTestCtl(t *testing.T, format string) (*ctl, *bytes.Buffer, domain.User) {
+	userRepo := memory.NewUserMemoryRepository(nil)
+	alice, err := userRepo.InsertOne(domain.User{Email: "alice@example.com", Username: "alice", Name: "Alice"})
+	require.NoError(t, err)
+	bob, err := userRepo.InsertOne(domain.User{Email: "bob@example.com", Username: "bob", Name: "Bob"})
+	require.NoError(t, err)
+	require.NoError(t, userRepo.RelateUsers(alice.ID, bob.ID))
+
+	var out bytes.Buffer
+	return newCtl(userRepo, service.NewUserService(userRepo), &printer{w: &out, format: format}), &out, alice
+}
+
+func writeImportFile(t *testing.T, name string, content string) string {
+	dir, err := ioutil.TempDir("", "golumnctl")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestGetUser(t *testing.T) {
+	c, out, alice := newTestCtl(t, OutputTable)
+	ctx := context.Background()
+
+	for _, ref := range []string{"@alice", "alice@example.com", publicid.Encode(alice.ID)} {
+		out.Reset()
+		require.NoError(t, c.run(ctx, []string{"get", ref}), ref)
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 2)
+		require.True(t, strings.HasPrefix(lines[0], "ID "))
+		require.Equal(t, []string{publicid.Encode(alice.ID), "alice", "alice@example.com", "Alice", "1", "0"},
+			strings.Fields(lines[1])[:6])
+	}
+
+	require.Error(t, c.run(ctx, []string{"get", "@nobody"}))
+	require.Error(t, c.run(ctx, []string{"get"}))
+	require.Error(t, c.run(ctx, []string{"unknown"}))
+}
+
+func TestGetUserJSON(t *testing.T) {
+	c, out, alice := newTestCtl(t, OutputJSON)
+	require.NoError(t, c.run(context.Background(), []string{"get", "@alice"}))
+
+	var user domain.User
+	require.NoError(t, json.Unmarshal(out.Bytes(), &user))
+	require.Equal(t, alice.ID, user.ID)
+	require.Equal(t, alice.Email, user.Email)
+}
+
+func TestCreateRenameDeleteRestoreUser(t *testing.T) {
+	c, out, _ := newTestCtl(t, OutputJSON)
+	ctx := context.Background()
+
+	require.NoError(t, c.run(ctx, []string{"create", "-email", "carol@example.com", "-username", "carol", "-name", "Carol"}))
+	var created domain.User
+	require.NoError(t, json.Unmarshal(out.Bytes(), &created))
+	require.Equal(t, "carol", created.Username)
+
+	err := c.run(ctx, []string{"create", "-email", "carol@example.com", "-username", "carol2", "-name", "Carol"})
+	require.Error(t, err, "create must not return existing user")
+
+	out.Reset()
+	require.NoError(t, c.run(ctx, []string{"rename", "@carol", "caroline"}))
+	require.Contains(t, out.String(), `"username": "caroline"`)
+
+	id := publicid.Encode(created.ID)
+	require.NoError(t, c.run(ctx, []string{"delete", id}))
+	require.Error(t, c.run(ctx, []string{"get", id}))
+	err = c.run(ctx, []string{"create", "-email", "carol@example.com", "-username", "carol3", "-name", "Carol"})
+	require.Contains(t, errorMessage(err), "restore it", "email of deleted user is reserved")
+	require.NoError(t, c.run(ctx, []string{"restore", id}))
+	require.NoError(t, c.run(ctx, []string{"get", "@caroline"}))
+}
+
+func TestListFollowers(t *testing.T) {
+	c, out, _ := newTestCtl(t, OutputJSON)
+	require.NoError(t, c.run(context.Background(), []string{"followers", "-limit", "5", "@alice"}))
+
+	var followers []domain.User
+	require.NoError(t, json.Unmarshal(out.Bytes(), &followers))
+	require.Len(t, followers, 1)
+	require.Equal(t, "bob", followers[0].Username)
+}
+
+func TestImportUsersCSV(t *testing.T) {
+	c, out, _ := newTestCtl(t, OutputJSON)
+	path := writeImportFile(t, "users.csv", "email,username,name,location\n"+
+		"carol@example.com,carol,Carol,Berlin\n"+
+		"alice@example.com,alice2,Alice\n"+
+		"dave@example.com,d,Dave,Paris\n"+
+		"erin@example.com,erinoslo,Erin,Oslo\n")
+
+	err := c.run(context.Background(), []string{"import", path})
+	require.EqualError(t, err, "2 of 4 users failed to import")
+
+	var results []importResult
+	require.NoError(t, json.Unmarshal(out.Bytes(), &results))
+	require.Len(t, results, 4)
+	require.Equal(t, []string{ImportCreated, ImportFailed, ImportFailed, ImportCreated},
+		[]string{results[0].Status, results[1].Status, results[2].Status, results[3].Status})
+	require.Equal(t, 3, results[1].Line, "record of wrong field count fails alone")
+	require.Contains(t, results[2].Error, "username", "validation details are reported")
+
+	user, err := c.users.GetByUsername("carol")
+	require.NoError(t, err)
+	require.Equal(t, "Berlin", user.Location)
+}
+
+func TestImportUsersJSONL(t *testing.T) {
+	c, out, alice := newTestCtl(t, OutputTable)
+	path := writeImportFile(t, "users.jsonl",
+		`{"email": "carol@example.com", "username": "carol", "name": "Carol", "followers_count": 99}`+"\n"+
+			"\n"+
+			`{"email": "alice@example.com", "username": "alice", "name": "Alice"}`+"\n"+
+			`{"email": `+"\n")
+
+	err := c.run(context.Background(), []string{"import", "-format", "jsonl", path})
+	require.EqualError(t, err, "1 of 3 users failed to import")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	require.Contains(t, lines[1], "carol")
+	require.Contains(t, lines[1], ImportCreated)
+	require.Contains(t, lines[2], publicid.Encode(alice.ID))
+	require.Contains(t, lines[2], ImportExists)
+	require.True(t, strings.HasPrefix(lines[3], "4 "), "line of invalid json is reported")
+
+	user, err := c.users.GetByUsername("carol")
+	require.NoError(t, err)
+	require.Zero(t, user.FollowersCount, "counters are not imported")
+}
+
+// failingEmailRepository fails every lookup by email
+type failingEmailRepository struct {
+	domain.UserRepository
+}
+
+func (repo failingEmailRepository) GetByEmailContext(context.Context, string) (domain.User, error) {
+	return domain.User{}, domain.ErrInternalServer.WithMessage("userrepo: find user by email fail")
+}
+
+func TestImportUsersLookupFailure(t *testing.T) {
+	c, out, _ := newTestCtl(t, OutputJSON)
+	c.users = failingEmailRepository{c.users}
+	path := writeImportFile(t, "users.jsonl", `{"email": "carol@example.com", "username": "carol", "name": "Carol"}`+"\n")
+
+	err := c.run(context.Background(), []string{"import", "-format", "jsonl", path})
+	require.EqualError(t, err, "1 of 1 users failed to import")
+
+	var results []importResult
+	require.NoError(t, json.Unmarshal(out.Bytes(), &results))
+	require.Len(t, results, 1)
+	require.Equal(t, ImportFailed, results[0].Status)
+	require.Contains(t, results[0].Error, "find user by email fail")
+
+	_, err = c.users.GetByUsername("carol")
+	require.Error(t, err, "user is not created when lookup fails")
+}
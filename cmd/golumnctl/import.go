@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/lib/publicid"
+)
+
+// Lists of import statuses
+const (
+	ImportCreated = "created"
+	ImportExists  = "exists"
+	ImportFailed  = "failed"
+)
+
+// importResult reports import of user at line of the input
+type importResult struct {
+	Line     int         `json:"line"`
+	ID       publicid.ID `json:"id,omitempty"`
+	Email    string      `json:"email"`
+	Username string      `json:"username"`
+	Status   string      `json:"status"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// userReader reads users to import one by one. Read returns io.EOF
+// at the end of input, or lineError when only the line is invalid.
+type userReader interface {
+	Read() (user domain.User, line int, err error)
+}
+
+// lineError is error of single line, reading may go on
+type lineError struct {
+	line int
+	err  error
+}
+
+func (e *lineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.line, e.err)
+}
+
+// importUsers creates users read from csv or jsonl file, users
+// whose email is taken are skipped. Every line is reported, and
+// failure of a line does not stop the import.
+func (c *ctl) importUsers(ctx context.Context, args []string) error {
+	fs := newFlagSet("import")
+	format := fs.String("format", "", "input format, csv or jsonl, by default file extension")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return usageError("import")
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	var reader userReader
+	switch *format {
+	case "csv":
+		csvReader, err := newCSVUserReader(input)
+		if err != nil {
+			return err
+		}
+		reader = csvReader
+	case "jsonl":
+		reader = newJSONLUserReader(input)
+	default:
+		return fmt.Errorf("unsupported import format %q, use -format csv or jsonl", *format)
+	}
+
+	results, err := c.importFrom(ctx, reader)
+	if printErr := c.printImport(results); err == nil {
+		err = printErr
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Status == ImportFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d users failed to import", failed, len(results))
+	}
+	return nil
+}
+
+func (c *ctl) importFrom(ctx context.Context, reader userReader) ([]importResult, error) {
+	results := []importResult{}
+	for {
+		user, line, err := reader.Read()
+		if err == io.EOF {
+			return results, nil
+		}
+		var lineErr *lineError
+		if errors.As(err, &lineErr) {
+			results = append(results, importResult{Line: lineErr.line, Status: ImportFailed, Error: lineErr.err.Error()})
+			continue
+		}
+		if err != nil {
+			return results, err
+		}
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		results = append(results, c.importUser(ctx, line, user))
+	}
+}
+
+func (c *ctl) importUser(ctx context.Context, line int, user domain.User) importResult {
+	result := importResult{Line: line, Email: user.Email, Username: user.Username}
+
+	existing, err := c.users.GetByEmailContext(ctx, user.Email)
+	if err == nil {
+		result.ID, result.Username, result.Status = publicid.ID(existing.ID), existing.Username, ImportExists
+		return result
+	}
+	if !errors.Is(err, domain.ErrUnknownResource) {
+		// email may be taken, failed lookup must not create duplicate
+		result.Status, result.Error = ImportFailed, errorMessage(err)
+		return result
+	}
+
+	// only profile is imported, not id, counters nor timestamps
+	profile := domain.User{
+		Email:         user.Email,
+		Username:      user.Username,
+		Name:          user.Name,
+		Location:      user.Location,
+		Description:   user.Description,
+		ProfileImgURL: user.ProfileImgURL,
+		TwitterName:   user.TwitterName,
+		FacebookName:  user.FacebookName,
+	}
+	created, err := c.service.CreateUserContext(ctx, profile)
+	if err != nil {
+		result.Status, result.Error = ImportFailed, strings.Replace(errorMessage(err), "\n  ", "; ", -1)
+		return result
+	}
+	result.ID, result.Status = publicid.ID(created.ID), ImportCreated
+	return result
+}
+
+func (c *ctl) printImport(results []importResult) error {
+	rows := make([][]string, len(results))
+	for i, result := range results {
+		id := ""
+		if result.ID != 0 {
+			id = publicid.Encode(uint64(result.ID))
+		}
+		rows[i] = []string{strconv.Itoa(result.Line), id, result.Email, result.Username, result.Status, result.Error}
+	}
+	return c.printer.print(results, []string{"LINE", "ID", "EMAIL", "USERNAME", "STATUS", "ERROR"}, rows)
+}
+
+// csvColumns sets field of user from csv column of the header
+var csvColumns = map[string]func(user *domain.User, value string){
+	"email":           func(user *domain.User, value string) { user.Email = value },
+	"username":        func(user *domain.User, value string) { user.Username = value },
+	"name":            func(user *domain.User, value string) { user.Name = value },
+	"location":        func(user *domain.User, value string) { user.Location = value },
+	"description":     func(user *domain.User, value string) { user.Description = value },
+	"profile_img_url": func(user *domain.User, value string) { user.ProfileImgURL = value },
+	"twitter_name":    func(user *domain.User, value string) { user.TwitterName = value },
+	"facebook_name":   func(user *domain.User, value string) { user.FacebookName = value },
+}
+
+// csvUserReader reads users from csv with header of csvColumns.
+// Lines are counted assuming no record spans several lines.
+type csvUserReader struct {
+	reader  *csv.Reader
+	columns []func(user *domain.User, value string)
+	line    int
+}
+
+func newCSVUserReader(r io.Reader) (*csvUserReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("csv header: %v", err)
+	}
+
+	columns := make([]func(user *domain.User, value string), len(header))
+	for i, name := range header {
+		column, ok := csvColumns[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("csv header: unknown column %q", name)
+		}
+		columns[i] = column
+	}
+	return &csvUserReader{reader: reader, columns: columns, line: 1}, nil
+}
+
+func (r *csvUserReader) Read() (domain.User, int, error) {
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return domain.User{}, 0, err
+	}
+	r.line++
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return domain.User{}, r.line, &lineError{line: r.line, err: parseErr.Err}
+	}
+	if err != nil {
+		return domain.User{}, r.line, err
+	}
+
+	var user domain.User
+	for i, value := range record {
+		r.columns[i](&user, value)
+	}
+	return user, r.line, nil
+}
+
+// jsonlUserReader reads users from json lines, one user object
+// per line with the fields of user json. Blank lines are skipped.
+type jsonlUserReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newJSONLUserReader(r io.Reader) *jsonlUserReader {
+	return &jsonlUserReader{scanner: bufio.NewScanner(r)}
+}
+
+func (r *jsonlUserReader) Read() (domain.User, int, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var user domain.User
+		if err := json.Unmarshal(data, &user); err != nil {
+			return domain.User{}, r.line, &lineError{line: r.line, err: err}
+		}
+		return user, r.line, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return domain.User{}, r.line, err
+	}
+	return domain.User{}, r.line, io.EOF
+}
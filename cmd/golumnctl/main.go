@@ -0,0 +1,79 @@
+// Command golumnctl manages golumn users from the command line. It
+// reads the same config file, GOLUMN_* env vars and flags as
+// golumn-server, followed by -o and the command:
+//
+//	golumnctl [flags] [-o table|json] <command> [args]
+//
+//	get <user>
+//	create -email EMAIL -username USERNAME -name NAME [-location L] [-description D]
+//	delete <user>
+//	restore <id>
+//	rename <user> <username>
+//	followers [-page N] [-limit N] <user>
+//	import [-format csv|jsonl] <file|->
+//
+// <user> is public id, @username or email. Deleted users can only
+// be restored by public id.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/iqdf/golumn-story-service/cmd/internal/app"
+)
+
+func main() {
+	fs := flag.NewFlagSet("golumnctl", flag.ContinueOnError)
+	output := fs.String("o", OutputTable, "output format, table or json")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: golumnctl [flags] <command> [args]\n\ncommands:\n%s\nflags:\n", commandUsage())
+		fs.PrintDefaults()
+	}
+	cfg, err := app.LoadConfig(fs, os.Args[1:], os.Getenv)
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if fs.NArg() == 0 || (*output != OutputTable && *output != OutputJSON) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	db, err := app.OpenDB(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer db.Close()
+
+	services, err := app.NewServices(db, cfg)
+	if err != nil {
+		fatal(err)
+	}
+
+	c := newCtl(services.Repos.Users, services.Users, &printer{w: os.Stdout, format: *output})
+	if err := c.run(ctx, fs.Args()); err != nil {
+		db.Close()
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "golumnctl: %s\n", errorMessage(err))
+	os.Exit(1)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/lib/publicid"
+)
+
+// Lists of output formats
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+var userHeader = []string{"ID", "USERNAME", "EMAIL", "NAME", "FOLLOWERS", "FOLLOWING", "CREATED AT"}
+
+// printer writes command output as table or json
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+// User prints single user, as json object
+func (p *printer) User(user domain.User) error {
+	return p.print(user, userHeader, [][]string{userRow(user)})
+}
+
+// Users prints list of users, as json array
+func (p *printer) Users(users []domain.User) error {
+	if users == nil {
+		users = []domain.User{}
+	}
+	rows := make([][]string, len(users))
+	for i, user := range users {
+		rows[i] = userRow(user)
+	}
+	return p.print(users, userHeader, rows)
+}
+
+// print writes v as json, or rows as table under header
+func (p *printer) print(v interface{}, header []string, rows [][]string) error {
+	if p.format == OutputJSON {
+		encoder := json.NewEncoder(p.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func userRow(user domain.User) []string {
+	createdAt := ""
+	if !user.CreatedAt.IsZero() {
+		createdAt = user.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		publicid.Encode(user.ID),
+		user.Username,
+		user.Email,
+		user.Name,
+		strconv.Itoa(user.FollowersCount),
+		strconv.Itoa(user.FollowingCount),
+		createdAt,
+	}
+}
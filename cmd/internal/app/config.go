@@ -0,0 +1,182 @@
+// Package app holds what golumn binaries share: config loading
+// and wiring of repositories on the database.
+package app
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/iqdf/golumn-story-service/lib/random"
+	"github.com/iqdf/golumn-story-service/user/service"
+)
+
+// envPrefix prefixes env var of every option, e.g. GOLUMN_LISTEN_ADDR
+const envPrefix = "GOLUMN_"
+
+// Log levels, from the most verbose
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelError = "error"
+)
+
+// Config of the server. Options are read from json file given by
+// -config flag or GOLUMN_CONFIG env, then GOLUMN_* env vars, then
+// flags, the latter overriding the former.
+type Config struct {
+	DSN             string   `json:"dsn"`
+	Dialect         string   `json:"dialect"`
+	ListenAddr      string   `json:"listen_addr"`
+	LogLevel        string   `json:"log_level"`
+	IDNode          uint     `json:"id_node"`
+	PublicIDSalt    string   `json:"public_id_salt"`
+	GracePeriod     Duration `json:"grace_period"`
+	PurgeInterval   Duration `json:"purge_interval"`
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+	Migrate         bool     `json:"migrate"`
+	Debug           bool     `json:"debug"`
+}
+
+// DefaultConfig returns config with default options, the DSN
+// and public id salt have no default and must be given
+func DefaultConfig() Config {
+	return Config{
+		Dialect:         "mysql",
+		ListenAddr:      ":8080",
+		LogLevel:        LogLevelInfo,
+		GracePeriod:     Duration(service.DefaultGracePeriod),
+		PurgeInterval:   Duration(time.Hour),
+		ShutdownTimeout: Duration(15 * time.Second),
+	}
+}
+
+// bind registers flag of each option to fs
+func (cfg *Config) bind(fs *flag.FlagSet) {
+	fs.StringVar(&cfg.DSN, "dsn", cfg.DSN, "database dsn, MySQL dsn must set parseTime=true")
+	fs.StringVar(&cfg.Dialect, "dialect", cfg.Dialect, "database dialect, mysql or postgres")
+	fs.StringVar(&cfg.ListenAddr, "listen-addr", cfg.ListenAddr, "http listen address")
+	fs.StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "log level, debug, info or error")
+	fs.UintVar(&cfg.IDNode, "id-node", cfg.IDNode, "snowflake id node, distinct for every running server")
+	fs.StringVar(&cfg.PublicIDSalt, "public-id-salt", cfg.PublicIDSalt, "secret salt of public ids, never change once ids are published")
+	fs.Var(&cfg.GracePeriod, "grace-period", "how long deleted user can be restored before purge")
+	fs.Var(&cfg.PurgeInterval, "purge-interval", "how often deleted users are purged")
+	fs.Var(&cfg.ShutdownTimeout, "shutdown-timeout", "how long shutdown waits for running requests")
+	fs.BoolVar(&cfg.Migrate, "migrate", cfg.Migrate, "apply pending schema migrations on startup")
+	fs.BoolVar(&cfg.Debug, "debug", cfg.Debug, "expose error cause and stack to clients, never in production")
+}
+
+// LoadConfig loads config from file, env vars given by getenv, and
+// command line args without the program name. Flags of the options
+// are registered to fs, which may have flags of its own.
+func LoadConfig(fs *flag.FlagSet, args []string, getenv func(string) string) (Config, error) {
+	cfg := DefaultConfig()
+	cfg.bind(fs)
+	configPath := fs.String("config", getenv(envPrefix+"CONFIG"), "json config file")
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	// flags are bound to cfg, keep the given ones to apply them last
+	options := flag.NewFlagSet("", flag.ContinueOnError)
+	cfg.bind(options)
+	given := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		if options.Lookup(f.Name) != nil {
+			given[f.Name] = f.Value.String()
+		}
+	})
+
+	cfg = DefaultConfig()
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return cfg, err
+		}
+	}
+
+	var err error
+	options.VisitAll(func(f *flag.Flag) {
+		name := envPrefix + strings.ToUpper(strings.Replace(f.Name, "-", "_", -1))
+		if value := getenv(name); value != "" && err == nil {
+			if err = options.Set(f.Name, value); err != nil {
+				err = fmt.Errorf("config: invalid %s env: %v", name, err)
+			}
+		}
+	})
+	if err != nil {
+		return cfg, err
+	}
+	for name, value := range given {
+		if err := options.Set(name, value); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
+func (cfg *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config: %v", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("config: invalid file %s: %v", path, err)
+	}
+	return nil
+}
+
+// Validate reports the first invalid option
+func (cfg *Config) Validate() error {
+	switch {
+	case cfg.Dialect != "mysql" && cfg.Dialect != "postgres":
+		return fmt.Errorf("config: unsupported dialect %q", cfg.Dialect)
+	case cfg.DSN == "":
+		return fmt.Errorf("config: dsn is required")
+	case cfg.Dialect == "mysql" && !strings.Contains(cfg.DSN, "parseTime=true"):
+		return fmt.Errorf("config: MySQL dsn must set parseTime=true")
+	case cfg.LogLevel != LogLevelDebug && cfg.LogLevel != LogLevelInfo && cfg.LogLevel != LogLevelError:
+		return fmt.Errorf("config: unknown log level %q", cfg.LogLevel)
+	case cfg.IDNode > random.MaxSnowflakeNode:
+		return fmt.Errorf("config: id node %d out of range [0, %d]", cfg.IDNode, random.MaxSnowflakeNode)
+	case cfg.PublicIDSalt == "":
+		return fmt.Errorf("config: public id salt is required")
+	case cfg.GracePeriod <= 0 || cfg.PurgeInterval <= 0 || cfg.ShutdownTimeout <= 0:
+		return fmt.Errorf("config: grace period, purge interval and shutdown timeout must be positive")
+	}
+	return nil
+}
+
+// Duration is time.Duration read from string like "720h"
+// in json file, env var and flag
+type Duration time.Duration
+
+// String implements flag.Value
+func (d *Duration) String() string {
+	return time.Duration(*d).String()
+}
+
+// Set implements flag.Value
+func (d *Duration) Set(value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be string like \"720h\"")
+	}
+	return d.Set(value)
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testDSN = "root:secret@tcp(localhost:3306)/golumn?parseTime=true"
+
+// loadConfig loads config from args and env given as map
+func loadConfig(args []string, env map[string]string) (Config, error) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	return LoadConfig(fs, args, func(name string) string { return env[name] })
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "golumn-server")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "config.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	cfg, err := loadConfig([]string{"-dsn", testDSN, "-public-id-salt", "secret"}, nil)
+	require.NoError(t, err)
+
+	expected := DefaultConfig()
+	expected.DSN, expected.PublicIDSalt = testDSN, "secret"
+	require.Equal(t, expected, cfg)
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := writeConfigFile(t, `{
+		"dsn": "`+testDSN+`",
+		"public_id_salt": "file",
+		"listen_addr": ":9000",
+		"log_level": "debug",
+		"grace_period": "48h"
+	}`)
+	env := map[string]string{
+		"GOLUMN_CONFIG":         path,
+		"GOLUMN_PUBLIC_ID_SALT": "env",
+		"GOLUMN_LISTEN_ADDR":    ":9001",
+		"GOLUMN_ID_NODE":        "7",
+	}
+
+	cfg, err := loadConfig([]string{"-listen-addr", ":9002"}, env)
+	require.NoError(t, err)
+	require.Equal(t, testDSN, cfg.DSN, "file overrides default")
+	require.Equal(t, LogLevelDebug, cfg.LogLevel)
+	require.Equal(t, Duration(48*time.Hour), cfg.GracePeriod)
+	require.Equal(t, "env", cfg.PublicIDSalt, "env overrides file")
+	require.Equal(t, uint(7), cfg.IDNode)
+	require.Equal(t, ":9002", cfg.ListenAddr, "flag overrides env and file")
+}
+
+func TestLoadInvalidConfig(t *testing.T) {
+	valid := []string{"-dsn", testDSN, "-public-id-salt", "secret"}
+	cases := map[string][]string{
+		"missing dsn":       {"-public-id-salt", "secret"},
+		"missing salt":      {"-dsn", testDSN},
+		"mysql parse time":  {"-dsn", "root@tcp(localhost)/golumn", "-public-id-salt", "secret"},
+		"unknown dialect":   append(valid, "-dialect", "sqlite3"),
+		"unknown log level": append(valid, "-log-level", "trace"),
+		"id node":           append(valid, "-id-node", "1024"),
+		"grace period":      append(valid, "-grace-period", "0s"),
+		"unknown flag":      append(valid, "-port", "80"),
+	}
+	for name, args := range cases {
+		_, err := loadConfig(args, nil)
+		require.Error(t, err, name)
+	}
+
+	_, err := loadConfig(valid, map[string]string{"GOLUMN_PURGE_INTERVAL": "hourly"})
+	require.Error(t, err, "invalid env")
+
+	path := writeConfigFile(t, `{"listen_address": ":80"}`)
+	_, err = loadConfig(append(valid, "-config", path), nil)
+	require.Error(t, err, "unknown key of config file")
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/lib/publicid"
+	"github.com/iqdf/golumn-story-service/lib/random"
+	repocommon "github.com/iqdf/golumn-story-service/lib/repository"
+	storymysql "github.com/iqdf/golumn-story-service/story/repository/mysql"
+	usermysql "github.com/iqdf/golumn-story-service/user/repository/mysql"
+	userpostgres "github.com/iqdf/golumn-story-service/user/repository/postgres"
+	"github.com/iqdf/golumn-story-service/user/service"
+)
+
+// Services are repositories and services wired on the database
+type Services struct {
+	Repos domain.Repositories
+	UoW   *repocommon.GormUnitOfWork
+	Users *service.UserService
+}
+
+// OpenDB opens database given by cfg
+func OpenDB(cfg Config) (*gorm.DB, error) {
+	return gorm.Open(cfg.Dialect, cfg.DSN)
+}
+
+// NewServices wires repositories and services on db. It sets the
+// default public id encoder, so it must be called on startup.
+func NewServices(db *gorm.DB, cfg Config) (*Services, error) {
+	publicid.SetDefault(publicid.NewEncoder(cfg.PublicIDSalt))
+	idGenerator, err := random.NewSnowflakeGenerator(uint16(cfg.IDNode))
+	if err != nil {
+		return nil, err
+	}
+
+	factory, errCvt := NewRepositoryFactory(cfg.Dialect, idGenerator)
+	uow := repocommon.NewGormUnitOfWork(db, factory, errCvt)
+	repos := factory(db)
+
+	users := service.NewUserServiceWithUnitOfWork(repos.Users, uow)
+	users.SetStoryRepositories(repos.Stories, repos.Revisions)
+	users.SetGracePeriod(time.Duration(cfg.GracePeriod))
+	return &Services{Repos: repos, UoW: uow, Users: users}, nil
+}
+
+// NewRepositoryFactory returns factory of repositories of dialect,
+// and converter of its db errors for the unit of work
+func NewRepositoryFactory(dialect string, rand usermysql.UIntRandomizer) (repocommon.RepositoryFactory, repocommon.ErrorConverter) {
+	if dialect == "postgres" {
+		factory := func(tx *gorm.DB) domain.Repositories {
+			stories := storymysql.NewStoryMySQLRepository(tx, rand)
+			stories.ErrCvt = repocommon.NewPostgresErrCvt()
+			revisions := storymysql.NewStoryRevisionMySQLRepository(tx, rand)
+			revisions.ErrCvt = repocommon.NewPostgresErrCvt()
+			return domain.Repositories{
+				Users:     userpostgres.NewUserPostgresRepository(tx, rand),
+				Stories:   stories,
+				Revisions: revisions,
+			}
+		}
+		return factory, repocommon.NewPostgresErrCvt()
+	}
+
+	factory := func(tx *gorm.DB) domain.Repositories {
+		return domain.Repositories{
+			Users:     usermysql.NewUserMySQLRepository(tx, rand),
+			Stories:   storymysql.NewStoryMySQLRepository(tx, rand),
+			Revisions: storymysql.NewStoryRevisionMySQLRepository(tx, rand),
+		}
+	}
+	return factory, repocommon.NewMySQLErrCvt()
+}
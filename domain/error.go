@@ -3,17 +3,37 @@
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/pkg/errors"
 )
 
-// Lists of Application Error Codes
+// StatusClientClosedRequest is the non-standard http status
+// (nginx convention) for request cancelled by client
+const StatusClientClosedRequest = 499
+
+// Lists of Application Error Codes, see ErrorCatalogue
 var (
-	AuthenticationFailCode   = 0x0041
-	UnknownResourceCode      = 0x0044
-	InvalidParamCode         = 0x0041
-	OperationUnsupportedCode = 0x0043
-	InternalErrorCode        = 0x0050
+	AuthenticationFailCode = RegisterErrorCode(0x0041, "AUTHENTICATION_FAILED",
+		http.StatusUnauthorized, "Invalid credentials or unrecognized keys")
+	InvalidParamCode = RegisterErrorCode(0x0042, "INVALID_PARAMETERS",
+		http.StatusBadRequest, "Client input is malformed, invalid or conflicts with existing resource")
+	OperationUnsupportedCode = RegisterErrorCode(0x0043, "OPERATION_NOT_SUPPORTED",
+		http.StatusForbidden, "Operation is not allowed for the user")
+	UnknownResourceCode = RegisterErrorCode(0x0044, "RESOURCE_NOT_FOUND",
+		http.StatusNotFound, "Requested resource does not exist or is not publicly available")
+	MethodNotAllowedCode = RegisterErrorCode(0x0045, "METHOD_NOT_ALLOWED",
+		http.StatusMethodNotAllowed, "HTTP method is not supported by the resource, see Allow header")
+	ResourceConflictCode = RegisterErrorCode(0x0046, "RESOURCE_CONFLICT",
+		http.StatusConflict, "Resource cannot be changed as other resources still refer to it")
+	RequestCancelledCode = RegisterErrorCode(0x0049, "REQUEST_CANCELLED",
+		StatusClientClosedRequest, "Request is cancelled, typically because client closed the connection")
+	InternalErrorCode = RegisterErrorCode(0x0050, "INTERNAL_ERROR",
+		http.StatusInternalServerError, "Unexpected server failure, the request may be retried later")
+	TransactionConflictCode = RegisterErrorCode(0x0052, "TRANSACTION_CONFLICT",
+		http.StatusConflict, "Concurrent requests conflict on the same resources, the request may be retried")
+	RequestTimeoutCode = RegisterErrorCode(0x0054, "REQUEST_TIMEOUT",
+		http.StatusGatewayTimeout, "Request deadline exceeded before the operation completes")
 )
 
 // AppError implements error containing application
@@ -22,9 +42,31 @@ type AppError struct {
 	httpCode int
 	code     int
 	cause    error
-	Msg      string `json:"errorMsg"`
+	Msg      string        `json:"errorMsg"`
+	Details  []ErrorDetail `json:"details,omitempty"`
+}
+
+// ErrorDetail describes what is wrong with a field of client input,
+// Code is machine-readable, e.g. "max_length", for client to map
+// the error back to form field
+type ErrorDetail struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
 }
 
+// Lists of ErrorDetail codes
+const (
+	DetailRequired   = "required"
+	DetailMinLength  = "min_length"
+	DetailMaxLength  = "max_length"
+	DetailDuplicate  = "duplicate"
+	DetailInvalid    = "invalid"
+	DetailNotFound   = "not_found"
+	DetailReferenced = "referenced"
+	DetailDeleted    = "deleted"
+)
+
 func (e *AppError) Error() string {
 	if e.cause != nil {
 		return e.Msg + ": " + e.cause.Error()
@@ -38,12 +80,37 @@ func OK() error { return &AppError{httpCode: 200, Msg: "Success"} }
 // Cause returns the underlying error cause of the wrapped error
 func (e *AppError) Cause() error { return e.cause }
 
+// Unwrap returns the cause for errors.Is and errors.As to inspect
+func (e *AppError) Unwrap() error { return e.cause }
+
+// Is reports whether target is an app error of the same code,
+// so errors.Is(err, ErrUnknownResource) matches any derived error
+// e.g. ErrUnknownResource.WithMessage("user not found")
+func (e *AppError) Is(target error) bool {
+	appErr, ok := target.(*AppError)
+	return ok && appErr.code == e.code
+}
+
+// AsAppError finds the first app error in the chain of err,
+// e.g. app error of repository wrapped by errors.Wrap
+func AsAppError(err error) (*AppError, bool) {
+	var appErr *AppError
+	ok := errors.As(err, &appErr)
+	return appErr, ok
+}
+
 // Message returns the summarised error message for client
 func (e *AppError) Message() string { return e.Msg }
 
 // Code returns application error identifier (the error code)
 func (e *AppError) Code() int { return e.code }
 
+// Name returns stable name of the error code, e.g. "RESOURCE_NOT_FOUND"
+func (e *AppError) Name() string {
+	errorCode, _ := LookupErrorCode(e.code)
+	return errorCode.Name
+}
+
 // HTTPCode returns associated http status code for the error
 func (e *AppError) HTTPCode() int {
 	if e.httpCode == 0 {
@@ -66,6 +133,7 @@ func (e *AppError) Wrap(err error, debug string) error {
 		code:     e.code,
 		httpCode: e.httpCode,
 		Msg:      e.Msg,
+		Details:  e.Details,
 	}
 }
 
@@ -81,6 +149,7 @@ func (e *AppError) Wrapf(err error, debugf string, args ...interface{}) error {
 		code:     e.code,
 		httpCode: e.httpCode,
 		Msg:      e.Msg,
+		Details:  e.Details,
 	}
 }
 
@@ -91,6 +160,7 @@ func (e *AppError) WithMessage(message string) error {
 		code:     e.code,
 		cause:    e.cause,
 		Msg:      e.Msg + ": " + message,
+		Details:  e.Details,
 	}
 }
 
@@ -102,18 +172,48 @@ func (e *AppError) WithMessagef(messagef string, args ...interface{}) error {
 		code:     e.code,
 		cause:    e.cause,
 		Msg:      e.Msg + ": " + fmt.Sprintf(messagef, args...),
+		Details:  e.Details,
+	}
+}
+
+// WithDetails returns an app error with details appended,
+// message summarises the fields they are about
+func (e *AppError) WithDetails(details ...ErrorDetail) error {
+	fields := make([]string, 0, len(details))
+	for _, detail := range details {
+		fields = append(fields, detail.Field)
+	}
+	return &AppError{
+		httpCode: e.httpCode,
+		code:     e.code,
+		cause:    e.cause,
+		Msg:      e.Msg + ": invalid " + strings.Join(fields, ", "),
+		Details:  append(append([]ErrorDetail(nil), e.Details...), details...),
+	}
+}
+
+// WithFieldMessagef returns an app error with formatted message
+// appended, also as detail of field identified by code
+func (e *AppError) WithFieldMessagef(field string, code string, messagef string, args ...interface{}) error {
+	message := fmt.Sprintf(messagef, args...)
+	return &AppError{
+		httpCode: e.httpCode,
+		code:     e.code,
+		cause:    e.cause,
+		Msg:      e.Msg + ": " + message,
+		Details:  append(append([]ErrorDetail(nil), e.Details...), ErrorDetail{Field: field, Code: code, Message: message}),
 	}
 }
 
 // ErrInternalServer translates exactly to shit happened
-var ErrInternalServer = AppError{
+var ErrInternalServer = &AppError{
 	httpCode: http.StatusInternalServerError,
 	code:     InternalErrorCode,
 	Msg:      "Internal Server Error",
 }
 
 // ErrAuthenticationFail unrecognised user or token keys
-var ErrAuthenticationFail = AppError{
+var ErrAuthenticationFail = &AppError{
 	httpCode: http.StatusUnauthorized,
 	code:     AuthenticationFailCode,
 	Msg:      "Invalid credentials or unrecognized keys",
@@ -121,24 +221,65 @@ func (e *AppError) WithMessagef(messagef string, args ...interface{}) error {
 
 // ErrOperationNotSupported returned when user have no permission
 // or hasn't pay the bill
-var ErrOperationNotSupported = AppError{
+var ErrOperationNotSupported = &AppError{
 	httpCode: http.StatusForbidden,
 	code:     OperationUnsupportedCode,
 	Msg:      "Insufficient Permission Required",
 }
 
-// ErrBadParameters returned when user have no permission
-// or hasn't pay the bill
-var ErrBadParameters = AppError{
+// ErrMethodNotAllowed returned when http method is not
+// supported by the resource, along with Allow header
+var ErrMethodNotAllowed = &AppError{
+	httpCode: http.StatusMethodNotAllowed,
+	code:     MethodNotAllowedCode,
+	Msg:      "Method not allowed",
+}
+
+// ErrBadParameters returned when client input is malformed,
+// invalid or conflicts with existing resource
+var ErrBadParameters = &AppError{
 	httpCode: http.StatusBadRequest,
 	code:     InvalidParamCode,
-	Msg:      "Insufficient Permission Required",
+	Msg:      "Invalid parameters",
 }
 
 // ErrUnknownResource due to resource does not exist or
 // not publicly available
-var ErrUnknownResource = AppError{
+var ErrUnknownResource = &AppError{
 	httpCode: http.StatusNotFound,
 	code:     UnknownResourceCode,
 	Msg:      "Requested resource not available",
 }
+
+// ErrResourceConflict returned when resource cannot be
+// deleted or changed while other resources refer to it
+var ErrResourceConflict = &AppError{
+	httpCode: http.StatusConflict,
+	code:     ResourceConflictCode,
+	Msg:      "Resource is still in use",
+}
+
+// ErrTransactionConflict returned when database aborts the
+// operation due to deadlock or lock wait timeout with
+// concurrent operations, retrying may succeed
+var ErrTransactionConflict = &AppError{
+	httpCode: http.StatusConflict,
+	code:     TransactionConflictCode,
+	Msg:      "Conflict with concurrent request, please retry",
+}
+
+// ErrRequestCancelled returned when request context is cancelled,
+// typically because client closed the connection
+var ErrRequestCancelled = &AppError{
+	httpCode: StatusClientClosedRequest,
+	code:     RequestCancelledCode,
+	Msg:      "Request cancelled",
+}
+
+// ErrRequestTimeout returned when request context deadline
+// exceeded before the operation completes
+var ErrRequestTimeout = &AppError{
+	httpCode: http.StatusGatewayTimeout,
+	code:     RequestTimeoutCode,
+	Msg:      "Request timed out",
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+//go:generate go run ../cmd/golumn-errors -o ../docs/error_codes.json
+
+// ErrorCode is an entry of the error code catalogue published to
+// clients. Both Code and Name are stable: once released, they are
+// never changed nor reused for another error.
+type ErrorCode struct {
+	Code        int    `json:"code"`
+	Name        string `json:"name"`
+	HTTPStatus  int    `json:"httpStatus"`
+	Description string `json:"description"`
+}
+
+var (
+	errorCodes     = map[int]ErrorCode{}
+	errorCodeNames = map[string]int{}
+)
+
+// RegisterErrorCode adds code to the catalogue and returns it. Panics
+// when code or name is already registered, so duplicates are rejected
+// at package initialization rather than confusing clients.
+func RegisterErrorCode(code int, name string, httpStatus int, description string) int {
+	if registered, ok := errorCodes[code]; ok {
+		panic(fmt.Sprintf("domain: error code %#04x of %s already registered by %s", code, name, registered.Name))
+	}
+	if registered, ok := errorCodeNames[name]; ok {
+		panic(fmt.Sprintf("domain: error name %s of %#04x already registered by %#04x", name, code, registered))
+	}
+
+	errorCodes[code] = ErrorCode{
+		Code:        code,
+		Name:        name,
+		HTTPStatus:  httpStatus,
+		Description: description,
+	}
+	errorCodeNames[name] = code
+	return code
+}
+
+// LookupErrorCode returns catalogue entry of code, if registered
+func LookupErrorCode(code int) (ErrorCode, bool) {
+	errorCode, ok := errorCodes[code]
+	return errorCode, ok
+}
+
+// ErrorCatalogue returns every registered error code, sorted by code
+func ErrorCatalogue() []ErrorCode {
+	catalogue := make([]ErrorCode, 0, len(errorCodes))
+	for _, errorCode := range errorCodes {
+		catalogue = append(catalogue, errorCode)
+	}
+	sort.Slice(catalogue, func(i, j int) bool {
+		return catalogue[i].Code < catalogue[j].Code
+	})
+	return catalogue
+}
+
+// WriteErrorCatalogue exports the catalogue to w as indented json
+func WriteErrorCatalogue(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(ErrorCatalogue())
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterDuplicateErrorCode(t *testing.T) {
+	require.Panics(t, func() {
+		RegisterErrorCode(InvalidParamCode, "SOME_NEW_ERROR", 400, "duplicate code")
+	})
+	require.Panics(t, func() {
+		RegisterErrorCode(0x7fff, "INVALID_PARAMETERS", 400, "duplicate name")
+	})
+	_, ok := LookupErrorCode(0x7fff)
+	require.False(t, ok, "rejected code must not be registered")
+}
+
+func TestErrorCatalogueCoversErrors(t *testing.T) {
+	appErrors := []*AppError{
+		ErrInternalServer,
+		ErrAuthenticationFail,
+		ErrOperationNotSupported,
+		ErrMethodNotAllowed,
+		ErrBadParameters,
+		ErrUnknownResource,
+		ErrResourceConflict,
+		ErrTransactionConflict,
+		ErrRequestCancelled,
+		ErrRequestTimeout,
+	}
+	for _, appErr := range appErrors {
+		errorCode, ok := LookupErrorCode(appErr.Code())
+		require.True(t, ok, "code of %q is not registered", appErr.Message())
+		require.Equal(t, appErr.HTTPCode(), errorCode.HTTPStatus, errorCode.Name)
+		require.Equal(t, errorCode.Name, appErr.Name())
+	}
+	require.Len(t, ErrorCatalogue(), len(appErrors))
+}
+
+func TestErrorCatalogueExported(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteErrorCatalogue(&buf))
+
+	exported, err := ioutil.ReadFile("../docs/error_codes.json")
+	require.NoError(t, err)
+	require.Equal(t, string(exported), buf.String(), "run go generate ./domain")
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppErrorIs(t *testing.T) {
+	err := ErrUnknownResource.WithMessage("user not found")
+	require.True(t, errors.Is(err, ErrUnknownResource))
+	require.False(t, errors.Is(err, ErrBadParameters))
+
+	wrapped := pkgerrors.Wrap(err, "userrepo: get by id fail")
+	require.True(t, errors.Is(wrapped, ErrUnknownResource), "app error wrapped by repository")
+}
+
+func TestAppErrorUnwrap(t *testing.T) {
+	err := ErrRequestCancelled.Wrap(context.Canceled, "userrepo: get by id aborted")
+	require.True(t, errors.Is(err, ErrRequestCancelled))
+	require.True(t, errors.Is(err, context.Canceled), "cause must be reachable")
+	require.Nil(t, ErrInternalServer.Unwrap())
+}
+
+func TestAsAppError(t *testing.T) {
+	cause := ErrBadParameters.WithFieldMessagef("username", DetailDuplicate, "conflict duplicate UserZero")
+	err := pkgerrors.Wrap(cause, "service: create user fail")
+
+	appErr, ok := AsAppError(err)
+	require.True(t, ok)
+	require.Same(t, cause, appErr)
+	require.Equal(t, InvalidParamCode, appErr.Code())
+
+	var target *AppError
+	require.True(t, errors.As(err, &target))
+	require.Equal(t, "username", target.Details[0].Field)
+
+	_, ok = AsAppError(errors.New("connection refused"))
+	require.False(t, ok)
+	_, ok = AsAppError(nil)
+	require.False(t, ok)
+}
@@ -0,0 +1,9 @@
+package domain
+
+// Metadata describes a page of paginated query result
+type Metadata struct {
+	Total      int    `json:"total"`                 // total items matching the query
+	Page       int    `json:"page"`                  // current page, starts from 1
+	Limit      int    `json:"limit"`                 // max items per page
+	NextCursor string `json:"next_cursor,omitempty"` // empty on the last page
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"encoding/json"
+
+	"github.com/iqdf/golumn-story-service/lib/publicid"
+)
+
+// Domain types keep uint64 database keys, while json serializes their
+// public ids (see publicid.ID). Each marshaller shadows id fields of
+// type alias, which has the same fields but none of the methods.
+
+// MarshalJSON serializes user with public id
+func (user User) MarshalJSON() ([]byte, error) {
+	type alias User
+	return json.Marshal(struct {
+		alias
+		ID publicid.ID `json:"id,omitempty"`
+	}{alias(user), publicid.ID(user.ID)})
+}
+
+// UnmarshalJSON deserializes user with public id
+func (user *User) UnmarshalJSON(data []byte) error {
+	type alias User
+	aux := struct {
+		*alias
+		ID publicid.ID `json:"id,omitempty"`
+	}{(*alias)(user), publicid.ID(user.ID)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	user.ID = uint64(aux.ID)
+	return nil
+}
+
+// MarshalJSON serializes story with public ids
+func (story Story) MarshalJSON() ([]byte, error) {
+	type alias Story
+	return json.Marshal(struct {
+		alias
+		ID       publicid.ID `json:"id"`
+		AuthorID publicid.ID `json:"author_id"`
+	}{alias(story), publicid.ID(story.ID), publicid.ID(story.AuthorID)})
+}
+
+// UnmarshalJSON deserializes story with public ids
+func (story *Story) UnmarshalJSON(data []byte) error {
+	type alias Story
+	aux := struct {
+		*alias
+		ID       publicid.ID `json:"id"`
+		AuthorID publicid.ID `json:"author_id"`
+	}{(*alias)(story), publicid.ID(story.ID), publicid.ID(story.AuthorID)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	story.ID, story.AuthorID = uint64(aux.ID), uint64(aux.AuthorID)
+	return nil
+}
+
+// MarshalJSON serializes story revision with public ids
+func (revision StoryRevision) MarshalJSON() ([]byte, error) {
+	type alias StoryRevision
+	return json.Marshal(struct {
+		alias
+		ID      publicid.ID `json:"id"`
+		StoryID publicid.ID `json:"story_id"`
+	}{alias(revision), publicid.ID(revision.ID), publicid.ID(revision.StoryID)})
+}
+
+// MarshalJSON serializes revision diff with public story id
+func (revisionDiff RevisionDiff) MarshalJSON() ([]byte, error) {
+	type alias RevisionDiff
+	return json.Marshal(struct {
+		alias
+		StoryID publicid.ID `json:"story_id"`
+	}{alias(revisionDiff), publicid.ID(revisionDiff.StoryID)})
+}
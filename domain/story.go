@@ -0,0 +1,96 @@
+package domain
+
+import "time"
+
+// StoryStatus describes publication state of a story
+type StoryStatus string
+
+// Lists of Story Status
+const (
+	StoryStatusDraft     StoryStatus = "draft"
+	StoryStatusPublished StoryStatus = "published"
+)
+
+// Story (article) written by an author
+type Story struct {
+	ID          uint64      `json:"id"`
+	AuthorID    uint64      `json:"author_id"`
+	Title       string      `json:"title"`
+	Subtitle    string      `json:"subtitle"`
+	Body        string      `json:"body"`
+	Slug        string      `json:"slug"`
+	Status      StoryStatus `json:"status"`
+	ReadingTime int         `json:"reading_time"` // in minutes
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	PublishedAt *time.Time  `json:"published_at,omitempty"`
+
+	// PublishedRevision is revision number pinned as live
+	// content of published story, 0 when story is draft
+	PublishedRevision int `json:"published_revision,omitempty"`
+}
+
+// IsPublished reports whether story is publicly available
+func (story *Story) IsPublished() bool {
+	return story.Status == StoryStatusPublished
+}
+
+// StoryService defines interface that a story-service layer
+// can provide as use-cases
+type StoryService interface {
+
+	// Story getter/query interfaces, callerID is id of requesting
+	// user or 0 when anonymous. Other than the author, caller gets
+	// published stories only, with their live content.
+	GetStory(callerID uint64, storyID uint64) (Story, error)
+	GetStoryBySlug(slug string) (Story, error)
+	ListAuthorStories(callerID uint64, authorID uint64, page int, limit int) ([]Story, Metadata, error)
+
+	// Story writer interfaces, only author can modify his/her story
+	// Each create/update saves the draft as new revision.
+	CreateStory(authorID uint64, story Story) (Story, error)
+	UpdateStory(authorID uint64, storyID uint64, story Story) (Story, error)
+	DeleteStory(authorID uint64, storyID uint64) error
+
+	// Story publication workflow. Publish pins revision number
+	// as live content, revision 0 pins the latest revision.
+	PublishStory(authorID uint64, storyID uint64, revision int) (Story, error)
+	UnpublishStory(authorID uint64, storyID uint64) (Story, error)
+
+	// Story revision history, restore saves the
+	// restored revision content as new revision.
+	ListRevisions(authorID uint64, storyID uint64, page int, limit int) ([]StoryRevision, Metadata, error)
+	DiffRevisions(authorID uint64, storyID uint64, from int, to int) (RevisionDiff, error)
+	RestoreRevision(authorID uint64, storyID uint64, revision int) (Story, error)
+}
+
+// StoryRepository defines interface that story-data
+// persistence layer (db, cache, elastic) can provide
+type StoryRepository interface {
+
+	// Query single story
+	GetByID(storyID uint64) (Story, error)
+	GetBySlug(slug string) (Story, error)
+
+	// Query paginate stories of an author
+	FetchByAuthor(authorID uint64, page int, limit int) ([]Story, Metadata, error)
+	FetchPublishedByAuthor(authorID uint64, page int, limit int) ([]Story, Metadata, error)
+
+	// Insert single story, story slug is made
+	// unique by suffixing it with the story id
+	InsertOne(story Story) (Story, error)
+
+	// Update content of single story, i.e. title, subtitle,
+	// body and reading time, empty values are written too
+	UpdateOne(storyID uint64, story Story) (Story, error)
+
+	// Update status, published revision and publish time
+	// of single story, including their zero values
+	UpdateStatus(storyID uint64, story Story) (Story, error)
+
+	// Delete single story
+	DeleteOne(storyID uint64) error
+
+	// Delete all stories of an author
+	DeleteByAuthor(authorID uint64) error
+}
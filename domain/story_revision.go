@@ -0,0 +1,63 @@
+package domain
+
+import "time"
+
+// StoryRevision is an immutable snapshot of story
+// content, saved every time the story draft is saved
+type StoryRevision struct {
+	ID        uint64    `json:"id"`
+	StoryID   uint64    `json:"story_id"`
+	Number    int       `json:"number"` // starts from 1, per story
+	Title     string    `json:"title"`
+	Subtitle  string    `json:"subtitle"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DiffOperation describes how a line changes between revisions
+type DiffOperation string
+
+// Lists of Diff Operation
+const (
+	DiffEqual  DiffOperation = "equal"
+	DiffInsert DiffOperation = "insert"
+	DiffDelete DiffOperation = "delete"
+)
+
+// DiffLine is a line of revision content with its change
+type DiffLine struct {
+	Operation DiffOperation `json:"op"`
+	Text      string        `json:"text"`
+}
+
+// RevisionDiff is line based difference of content
+// between two revisions of the same story
+type RevisionDiff struct {
+	StoryID  uint64     `json:"story_id"`
+	From     int        `json:"from"`
+	To       int        `json:"to"`
+	Title    []DiffLine `json:"title"`
+	Subtitle []DiffLine `json:"subtitle"`
+	Body     []DiffLine `json:"body"`
+}
+
+// StoryRevisionRepository defines interface that story
+// revision persistence layer can provide. Revisions are
+// never updated once inserted.
+type StoryRevisionRepository interface {
+
+	// Query single revision by its per-story number
+	GetRevision(storyID uint64, number int) (StoryRevision, error)
+
+	// Query paginate revisions of a story, newest first
+	ListRevisions(storyID uint64, page int, limit int) ([]StoryRevision, Metadata, error)
+
+	// Insert revision as the next revision number of the story
+	InsertRevision(revision StoryRevision) (StoryRevision, error)
+
+	// Delete all revisions of a story
+	DeleteByStory(storyID uint64) error
+
+	// Delete all revisions of stories of an author
+	DeleteByAuthor(authorID uint64) error
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "context"
+
+// Repositories groups repositories of a unit of work,
+// all of them are bound to the same transaction
+type Repositories struct {
+	Users     UserRepository
+	Stories   StoryRepository
+	Revisions StoryRevisionRepository
+}
+
+// UnitOfWork defines interface that persistence layer can
+// provide to run use-cases touching several tables atomically
+type UnitOfWork interface {
+
+	// Do runs fn within single transaction, committed when fn
+	// returns nil and rolled back otherwise. fn MUST only use the
+	// given repos, and may be re-run when transaction deadlocks.
+	Do(ctx context.Context, fn func(repos Repositories) error) error
+}
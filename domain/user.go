@@ -1,20 +1,28 @@
 package domain
 
+import (
+	"context"
+	"io"
+	"time"
+)
+
 // User ...
 type User struct {
-	ID             uint64 `json:"id;omitempty"`    // get - owner only
-	Email          string `json:"email;omitempty"` // create; get - owner only
-	Username       string `json:"username"`        // create; get - public
-	Name           string `json:"name"`
-	URL            string `json:"url"`
-	ProfileImgURL  string `json:"profile_img_url"`
-	Location       string `json:"location"`
-	Description    string `json:"description"`
-	IsMe           bool   `json:"isme"`
-	FollowersCount int    `json:"followers_count"`
-	FollowingCount int    `json:"following_count"`
-	TwitterName    string `json:"twitter_name"`
-	FacebookName   string `json:"facebook_name"`
+	ID             uint64    `json:"id,omitempty"`    // get - owner only
+	Email          string    `json:"email,omitempty"` // create; get - owner only
+	Username       string    `json:"username"`        // create; get - public
+	Name           string    `json:"name"`
+	URL            string    `json:"url"`
+	ProfileImgURL  string    `json:"profile_img_url"`
+	Location       string    `json:"location"`
+	Description    string    `json:"description"`
+	IsMe           bool      `json:"isme"`
+	FollowersCount int       `json:"followers_count"`
+	FollowingCount int       `json:"following_count"`
+	TwitterName    string    `json:"twitter_name"`
+	FacebookName   string    `json:"facebook_name"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 // GetURL ...
@@ -25,6 +33,15 @@ func (user *User) GetURL() string {
 	return "/@" + user.Username
 }
 
+// ExportFormat is archive format of user data export
+type ExportFormat string
+
+// Lists of Export Format
+const (
+	ExportFormatJSON ExportFormat = "json" // single json document
+	ExportFormatZip  ExportFormat = "zip"  // zip of one json file per section
+)
+
 // UserService defines interface that a user-service layer
 // can provide as use-cases
 type UserService interface {
@@ -35,7 +52,12 @@ type UserService interface {
 
 	// User writer interfaces
 	GetOrCreateUser(email string, user User) (User, error)
+	CreateUser(user User) (User, error)
+
+	// User account deletion is soft, the account can be
+	// restored until purged after a grace period
 	DeleteUser(userID uint64) error
+	RestoreUser(userID uint64) (User, error)
 
 	// User updater interfaces
 	UpdateUsername(userID uint64, user User) (User, error)
@@ -50,6 +72,22 @@ type UserService interface {
 
 	// User Image Profile
 	// TODO: UploadProfileImage()
+
+	// Export everything stored about user (profile, followership and
+	// authored content) to w, streamed as archive of given format
+	ExportUserData(userID uint64, format ExportFormat, w io.Writer) error
+
+	// Context variants of the above use-cases. Cancellation or deadline
+	// of ctx aborts pending queries with ErrRequestCancelled/ErrRequestTimeout
+	GetUserProfileContext(ctx context.Context, username string) (User, error)
+	GetOrCreateUserContext(ctx context.Context, email string, user User) (User, error)
+	CreateUserContext(ctx context.Context, user User) (User, error)
+	DeleteUserContext(ctx context.Context, userID uint64) error
+	RestoreUserContext(ctx context.Context, userID uint64) (User, error)
+	UpdateUsernameContext(ctx context.Context, userID uint64, user User) (User, error)
+	FollowUserContext(ctx context.Context, userID uint64, followedUsername string) (User, error)
+	UnfollowUserContext(ctx context.Context, userID uint64, followedUsername string) (User, error)
+	ExportUserDataContext(ctx context.Context, userID uint64, format ExportFormat, w io.Writer) error
 }
 
 // UserRepository defines interface that user-data
@@ -61,18 +99,56 @@ type UserRepository interface {
 	GetByEmail(email string) (User, error)
 	GetByUsername(username string) (User, error)
 
-	// Query paginate many users
-	// TODO:FetchMany(userFilter User, page int, limit int) ([]User, Metadata, error)
+	// Query paginate many users, filtered by name prefix,
+	// location and username of non-empty userFilter fields
+	FetchMany(userFilter User, page int, limit int) ([]User, Metadata, error)
+
+	// Query paginate followers/following of a user
+	ListFollowers(userID uint64, page int, limit int) ([]User, error)
+	ListFollowing(userID uint64, page int, limit int) ([]User, error)
 
 	// Insert single user
 	InsertOne(user User) (User, error)
 
-	// Update single user
+	// Update single user, only non-empty fields of user are
+	// updated and returned along with UpdatedAt, not the whole user
 	UpdateOne(userID uint64, user User) (User, error)
 
 	// Relate user follower-followed relationship
-	//TODO: RelateUsers(followedID uint64, followerID uint64) (error)
+	RelateUsers(followedID uint64, followerID uint64) error
+	UnrelateUsers(followedID uint64, followerID uint64) error
 
-	// Delete single user
+	// Soft delete single user. Deleted user is not found by any
+	// query, yet its email and username stay reserved until purged,
+	// and counters of related users keep counting it until purged
 	DeleteOne(userID uint64) error
+
+	// Restore single user deleted after deletedAfter
+	RestoreOne(userID uint64, deletedAfter time.Time) (User, error)
+
+	// Query users deleted before deletedBefore, oldest first. Unlike
+	// paginated queries limit is not bounded, the purger lists past
+	// users it failed to purge with it
+	ListDeleted(deletedBefore time.Time, limit int) ([]User, error)
+
+	// Hard delete single deleted user along with its followership,
+	// counters of the users it follows/followed by are updated
+	PurgeOne(userID uint64) error
+
+	// Context variants of the above. Cancellation or deadline of ctx
+	// aborts the query with ErrRequestCancelled/ErrRequestTimeout
+	GetByIDContext(ctx context.Context, userID uint64) (User, error)
+	GetByEmailContext(ctx context.Context, email string) (User, error)
+	GetByUsernameContext(ctx context.Context, username string) (User, error)
+	FetchManyContext(ctx context.Context, userFilter User, page int, limit int) ([]User, Metadata, error)
+	ListFollowersContext(ctx context.Context, userID uint64, page int, limit int) ([]User, error)
+	ListFollowingContext(ctx context.Context, userID uint64, page int, limit int) ([]User, error)
+	InsertOneContext(ctx context.Context, user User) (User, error)
+	UpdateOneContext(ctx context.Context, userID uint64, user User) (User, error)
+	RelateUsersContext(ctx context.Context, followedID uint64, followerID uint64) error
+	UnrelateUsersContext(ctx context.Context, followedID uint64, followerID uint64) error
+	DeleteOneContext(ctx context.Context, userID uint64) error
+	RestoreOneContext(ctx context.Context, userID uint64, deletedAfter time.Time) (User, error)
+	ListDeletedContext(ctx context.Context, deletedBefore time.Time, limit int) ([]User, error)
+	PurgeOneContext(ctx context.Context, userID uint64) error
 }
@@ -4,8 +4,10 @@ go 1.14
 
 require (
 	github.com/DATA-DOG/go-sqlmock v1.4.1
+	github.com/go-sql-driver/mysql v1.4.1
 	github.com/go-test/deep v1.0.6
 	github.com/jinzhu/gorm v1.9.12
+	github.com/lib/pq v1.3.0
 	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e // indirect
 	github.com/pkg/errors v0.9.1
 	github.com/satori/go.uuid v1.2.0
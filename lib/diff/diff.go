@@ -0,0 +1,66 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/iqdf/golumn-story-service/domain"
+)
+
+// Lines returns line based difference between text a and b,
+// computed from the longest common subsequence of their lines.
+func Lines(a, b string) []domain.DiffLine {
+	return Diff(splitLines(a), splitLines(b))
+}
+
+// Diff returns difference between list of lines a and b. Deleted lines
+// of a are listed before inserted lines of b at the same position.
+func Diff(a, b []string) []domain.DiffLine {
+	// lcs[i][j] is length of longest common
+	// subsequence of lines a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := make([]domain.DiffLine, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, domain.DiffLine{Operation: domain.DiffEqual, Text: a[i]})
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, domain.DiffLine{Operation: domain.DiffDelete, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, domain.DiffLine{Operation: domain.DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, domain.DiffLine{Operation: domain.DiffDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, domain.DiffLine{Operation: domain.DiffInsert, Text: b[j]})
+	}
+	return lines
+}
+
+// splitLines splits text into lines, empty text has no line
+func splitLines(text string) []string {
+	if len(text) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,43 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iqdf/golumn-story-service/domain"
+)
+
+func TestLines(t *testing.T) {
+	line := func(operation domain.DiffOperation) func(string) domain.DiffLine {
+		return func(text string) domain.DiffLine {
+			return domain.DiffLine{Operation: operation, Text: text}
+		}
+	}
+	equal, insert, delete := line(domain.DiffEqual), line(domain.DiffInsert), line(domain.DiffDelete)
+
+	tests := []struct {
+		name string
+		a, b string
+		want []domain.DiffLine
+	}{
+		{"both empty", "", "", []domain.DiffLine{}},
+		{"all inserted", "", "a\nb", []domain.DiffLine{insert("a"), insert("b")}},
+		{"all deleted", "a\nb\n", "", []domain.DiffLine{delete("a"), delete("b")}},
+		{"unchanged", "a\nb", "a\nb\n", []domain.DiffLine{equal("a"), equal("b")}},
+		{
+			"line replaced", "a\nb\nc", "a\nx\nc",
+			[]domain.DiffLine{equal("a"), delete("b"), insert("x"), equal("c")},
+		},
+		{
+			"line moved", "a\nb\nc", "b\nc\na",
+			[]domain.DiffLine{delete("a"), equal("b"), equal("c"), insert("a")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Lines(tt.a, tt.b))
+		})
+	}
+}
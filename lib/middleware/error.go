@@ -0,0 +1,117 @@
+// Package middleware provides http middleware shared by delivery
+// layers: request id assignment, authenticated principal and
+// rendering of handler errors.
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	pkgerrors "github.com/pkg/errors"
+
+	"github.com/iqdf/golumn-story-service/domain"
+)
+
+// HandlerFunc is http handler returning error instead of writing
+// it, error is rendered to client by ErrorRenderer.Handle
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ErrorResponse is the error envelope returned to client. Cause
+// and Stack are internal details, rendered in debug mode only.
+type ErrorResponse struct {
+	Code      int                  `json:"errorCode"`
+	Name      string               `json:"errorName"`
+	Message   string               `json:"errorMsg"`
+	Details   []domain.ErrorDetail `json:"details,omitempty"`
+	RequestID string               `json:"requestId,omitempty"`
+	Cause     string               `json:"cause,omitempty"`
+	Stack     []string             `json:"stack,omitempty"`
+}
+
+// ErrorRenderer renders errors returned by handlers as
+// ErrorResponse, and logs their full cause with request id
+type ErrorRenderer struct {
+	logger *log.Logger
+	debug  bool
+}
+
+// NewErrorRenderer creates new ErrorRenderer logging to logger.
+// Debug exposes cause and stack trace of errors to client,
+// it MUST never be enabled in production.
+func NewErrorRenderer(logger *log.Logger, debug bool) *ErrorRenderer {
+	if logger == nil {
+		logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	return &ErrorRenderer{logger: logger, debug: debug}
+}
+
+// Handle adapts fn to http.HandlerFunc rendering its error, if any.
+// Fn must not write to w before returning error.
+func (renderer *ErrorRenderer) Handle(fn HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := fn(w, r); err != nil {
+			renderer.WriteError(w, r, err)
+		}
+	}
+}
+
+// WriteError renders err as ErrorResponse with status of its
+// HTTPCode. Errors without *domain.AppError in their chain are
+// rendered as domain.ErrInternalServer.
+func (renderer *ErrorRenderer) WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	appErr, ok := domain.AsAppError(err)
+	if !ok {
+		appErr = domain.ErrInternalServer
+	}
+
+	requestID := RequestIDFromContext(r.Context())
+	if len(requestID) == 0 {
+		requestID = newRequestID()
+		w.Header().Set(RequestIDHeader, requestID)
+	}
+	renderer.logger.Printf("request_id=%s method=%s path=%s status=%d error=%q",
+		requestID, r.Method, r.URL.Path, appErr.HTTPCode(), err.Error())
+
+	response := ErrorResponse{
+		Code:      appErr.Code(),
+		Name:      appErr.Name(),
+		Message:   appErr.Message(),
+		Details:   appErr.Details,
+		RequestID: requestID,
+	}
+	if renderer.debug {
+		response.Cause = err.Error()
+		response.Stack = stackTrace(err)
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(appErr.HTTPCode())
+	json.NewEncoder(w).Encode(response)
+}
+
+type stackTracer interface {
+	StackTrace() pkgerrors.StackTrace
+}
+
+// stackTrace returns frames of the innermost pkg/errors stack
+// trace in the chain of err, the closest to where it occurred
+func stackTrace(err error) []string {
+	var trace pkgerrors.StackTrace
+	for ; err != nil; err = errors.Unwrap(err) {
+		if tracer, ok := err.(stackTracer); ok {
+			trace = tracer.StackTrace()
+		}
+	}
+
+	frames := make([]string, 0, len(trace))
+	for _, frame := range trace {
+		// "%+v" formats frame as "function\n\tfile:line"
+		frames = append(frames, strings.Replace(fmt.Sprintf("%+v", frame), "\n\t", " ", 1))
+	}
+	return frames
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iqdf/golumn-story-service/domain"
+)
+
+var errDriver = errors.New("dial tcp 10.0.0.7:3306: connection refused")
+
+// serve performs request against fn handled by renderer in
+// debug mode or not, returns response and log output
+func serve(t *testing.T, debug bool, fn HandlerFunc, requestID string) (*httptest.ResponseRecorder, ErrorResponse, string) {
+	var logs bytes.Buffer
+	renderer := NewErrorRenderer(log.New(&logs, "", 0), debug)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/abc", nil)
+	if requestID != "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+	rec := httptest.NewRecorder()
+	RequestID(renderer.Handle(fn)).ServeHTTP(rec, req)
+
+	var response ErrorResponse
+	if rec.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	}
+	return rec, response, logs.String()
+}
+
+func TestRenderAppError(t *testing.T) {
+	rec, response, logs := serve(t, false, func(w http.ResponseWriter, r *http.Request) error {
+		return domain.ErrUnknownResource.Wrap(errDriver, "userrepo: get by id fail")
+	}, "req-42")
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Equal(t, "req-42", rec.Header().Get(RequestIDHeader))
+	require.Equal(t, ErrorResponse{
+		Code:      domain.UnknownResourceCode,
+		Name:      "RESOURCE_NOT_FOUND",
+		Message:   domain.ErrUnknownResource.Message(),
+		RequestID: "req-42",
+	}, response, "cause must not leak in production")
+	require.NotContains(t, rec.Body.String(), "10.0.0.7")
+
+	require.Contains(t, logs, "request_id=req-42")
+	require.Contains(t, logs, errDriver.Error(), "full cause must be logged")
+}
+
+func TestRenderUnknownErrorAsInternal(t *testing.T) {
+	rec, response, logs := serve(t, false, func(w http.ResponseWriter, r *http.Request) error {
+		return errDriver
+	}, "")
+
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.Equal(t, domain.InternalErrorCode, response.Code)
+	require.NotContains(t, rec.Body.String(), "10.0.0.7")
+	require.NotEmpty(t, response.RequestID, "request id must be generated")
+	require.Contains(t, logs, "request_id="+response.RequestID)
+}
+
+func TestRenderDebugStackTrace(t *testing.T) {
+	_, response, _ := serve(t, true, func(w http.ResponseWriter, r *http.Request) error {
+		return domain.ErrInternalServer.Wrap(errDriver, "userrepo: insert one user fail")
+	}, "")
+
+	require.Contains(t, response.Cause, errDriver.Error())
+	require.NotEmpty(t, response.Stack)
+	require.Contains(t, response.Stack[0], "(*AppError).Wrap")
+}
+
+func TestRenderNoError(t *testing.T) {
+	rec, _, logs := serve(t, false, func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}, "")
+
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Empty(t, logs)
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	require.Empty(t, RequestIDFromContext(req.Context()))
+
+	var requestID string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = RequestIDFromContext(r.Context())
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	require.NotEmpty(t, requestID)
+	require.Equal(t, requestID, rec.Header().Get(RequestIDHeader))
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	_, ok := PrincipalFromContext(context.Background())
+	require.False(t, ok)
+
+	userID, ok := PrincipalFromContext(WithPrincipal(context.Background(), 42))
+	require.True(t, ok)
+	require.Equal(t, uint64(42), userID)
+}
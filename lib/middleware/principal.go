@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"context"
+)
+
+type principalKey struct{}
+
+// WithPrincipal returns copy of ctx carrying id of the authenticated
+// user, set by authentication middleware once the request is verified
+func WithPrincipal(ctx context.Context, userID uint64) context.Context {
+	return context.WithValue(ctx, principalKey{}, userID)
+}
+
+// PrincipalFromContext returns id of the authenticated user,
+// ok is false if the request is not authenticated
+func PrincipalFromContext(ctx context.Context) (userID uint64, ok bool) {
+	userID, ok = ctx.Value(principalKey{}).(uint64)
+	return userID, ok
+}
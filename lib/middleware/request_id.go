@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// RequestIDHeader carries request id from client or
+// proxy, and back to client in the response
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds request id accepted from client,
+// longer ids are replaced to keep logs readable
+const maxRequestIDLength = 64
+
+type requestIDKey struct{}
+
+// RequestID assigns id to every request, taken from RequestIDHeader
+// or generated, and echoes it in the response header. The id is
+// available to handlers through RequestIDFromContext.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if len(requestID) == 0 || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), requestID)))
+	})
+}
+
+// WithRequestID returns copy of ctx carrying request id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns id of request assigned by
+// RequestID middleware, or empty string if there is none
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+func newRequestID() string {
+	return uuid.NewV4().String()
+}
@@ -0,0 +1,216 @@
+// Package migrations keeps the database schema as ordered, versioned
+// SQL migrations for each supported dialect. Applied versions are
+// recorded in schema_migrations table.
+package migrations
+
+import (
+	// import built-in libraries
+	"fmt"
+	"sort"
+	"time"
+
+	// import third-party libraries
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// Migration is single schema change. Up and Down are run statement
+// by statement, as the mysql driver does not allow multi statements.
+type Migration struct {
+	Version int
+	Name    string
+	Up      []string
+	Down    []string
+}
+
+// MigrationStatus tells whether migration is applied and when
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// dialect knows the migrations and schema_migrations table of database
+type dialect struct {
+	migrations  []Migration
+	createTable string
+}
+
+var dialects = map[string]dialect{
+	"mysql": {
+		migrations: mysqlMigrations,
+		createTable: "CREATE TABLE IF NOT EXISTS `schema_migrations` (" +
+			"`version` bigint NOT NULL," +
+			"`name` VARCHAR(128) NOT NULL," +
+			"`applied_at` DATETIME NOT NULL, " +
+			"PRIMARY KEY (`version`))",
+	},
+	"postgres": {
+		migrations: postgresMigrations,
+		createTable: `CREATE TABLE IF NOT EXISTS "schema_migrations" (` +
+			`"version" bigint NOT NULL,` +
+			`"name" VARCHAR(128) NOT NULL,` +
+			`"applied_at" timestamp with time zone NOT NULL, ` +
+			`PRIMARY KEY ("version"))`,
+	},
+}
+
+// Migrations returns migrations of given gorm dialect name
+// ("mysql" or "postgres") ordered by version
+func Migrations(dialectName string) ([]Migration, error) {
+	d, ok := dialects[dialectName]
+	if !ok {
+		return nil, fmt.Errorf("migrations: unsupported dialect %q", dialectName)
+	}
+	migrations := append([]Migration(nil), d.migrations...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies and rolls back migrations of the database
+type Migrator struct {
+	db         *gorm.DB
+	dialect    dialect
+	migrations []Migration
+	now        func() time.Time
+}
+
+// NewMigrator creates new Migrator for the dialect of db
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	name := db.Dialect().GetName()
+	migrations, err := Migrations(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db,
+		dialect:    dialects[name],
+		migrations: migrations,
+		now:        time.Now,
+	}, nil
+}
+
+// Status returns every known migration with its applied time
+func (migrator *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := migrator.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(migrator.migrations))
+	for i, migration := range migrator.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses[i] = MigrationStatus{Migration: migration, Applied: ok, AppliedAt: appliedAt}
+	}
+	return statuses, nil
+}
+
+// Up applies every pending migration in order of version,
+// returns the applied migrations. It stops at the first failure.
+func (migrator *Migrator) Up() ([]Migration, error) {
+	applied, err := migrator.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for _, migration := range migrator.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := migrator.run(migration, migration.Up, func(tx *gorm.DB) error {
+			return tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+				migration.Version, migration.Name, migrator.now().UTC()).Error
+		})
+		if err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down rolls back at most steps applied migrations, latest first,
+// returns the rolled back migrations. It stops at the first failure.
+func (migrator *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := migrator.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]int, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	done := []Migration{}
+	for _, version := range versions {
+		if len(done) >= steps {
+			break
+		}
+		migration, ok := migrator.find(version)
+		if !ok {
+			return done, fmt.Errorf("migrations: applied version %d is unknown, cannot roll back", version)
+		}
+		err := migrator.run(migration, migration.Down, func(tx *gorm.DB) error {
+			return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version).Error
+		})
+		if err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// run executes statements and records the migration in one transaction.
+// MySQL commits DDL statements implicitly, so failed MySQL migration
+// may be left partially applied and needs manual repair.
+func (migrator *Migrator) run(migration Migration, statements []string, record func(tx *gorm.DB) error) error {
+	err := migrator.db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return record(tx)
+	})
+	return errors.Wrapf(err, "migrations: migration %04d_%s fail", migration.Version, migration.Name)
+}
+
+// applied creates schema_migrations table if missing,
+// returns applied time of each applied version
+func (migrator *Migrator) applied() (map[int]time.Time, error) {
+	if err := migrator.db.Exec(migrator.dialect.createTable).Error; err != nil {
+		return nil, errors.Wrap(err, "migrations: create schema_migrations fail")
+	}
+
+	rows, err := migrator.db.Raw("SELECT version, applied_at FROM schema_migrations").Rows()
+	if err != nil {
+		return nil, errors.Wrap(err, "migrations: query schema_migrations fail")
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, errors.Wrap(err, "migrations: scan schema_migrations fail")
+		}
+		applied[version] = appliedAt
+	}
+	return applied, errors.Wrap(rows.Err(), "migrations: query schema_migrations fail")
+}
+
+func (migrator *Migrator) find(version int) (Migration, bool) {
+	for _, migration := range migrator.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
@@ -0,0 +1,151 @@
+package migrations
+
+import (
+	// import built-in libraries
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	// import third-party libraries
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	createTableStr = regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS `schema_migrations`")
+	selectStr      = regexp.QuoteMeta("SELECT version, applied_at FROM schema_migrations")
+	insertStr      = regexp.QuoteMeta("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)")
+	deleteStr      = regexp.QuoteMeta("DELETE FROM schema_migrations WHERE version = ?")
+	appliedAt      = time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func newTestMigrator(t *testing.T) (*Migrator, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := gorm.Open("mysql", sqlDB)
+	require.NoError(t, err)
+
+	migrator, err := NewMigrator(db)
+	require.NoError(t, err)
+	migrator.now = func() time.Time { return appliedAt }
+	return migrator, mock
+}
+
+// expectApplied expects query of schema_migrations returning versions
+func expectApplied(mock sqlmock.Sqlmock, versions ...int) {
+	rows := sqlmock.NewRows([]string{"version", "applied_at"})
+	for _, version := range versions {
+		rows.AddRow(version, appliedAt)
+	}
+	mock.ExpectExec(createTableStr).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(selectStr).WillReturnRows(rows)
+}
+
+func expectStatements(mock sqlmock.Sqlmock, statements []string) {
+	for _, statement := range statements {
+		mock.ExpectExec(regexp.QuoteMeta(statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+}
+
+func TestMigrationsAreOrdered(t *testing.T) {
+	for name := range dialects {
+		migrations, err := Migrations(name)
+		require.NoError(t, err)
+		require.NotEmpty(t, migrations)
+
+		for i, migration := range migrations {
+			require.Equal(t, i+1, migration.Version, "%s versions must be sequential", name)
+			require.NotEmpty(t, migration.Name)
+			require.NotEmpty(t, migration.Up)
+			require.NotEmpty(t, migration.Down, "%s migration %d must be reversible", name, migration.Version)
+		}
+	}
+
+	mysql, _ := Migrations("mysql")
+	postgres, _ := Migrations("postgres")
+	require.Equal(t, len(mysql), len(postgres), "every migration must be written for both dialects")
+	for i := range mysql {
+		require.Equal(t, mysql[i].Name, postgres[i].Name)
+	}
+
+	_, err := Migrations("sqlite3")
+	require.Error(t, err)
+}
+
+func TestShouldApplyPendingMigrations(t *testing.T) {
+	migrator, mock := newTestMigrator(t)
+	expectApplied(mock, 1)
+
+	pending := migrator.migrations[1:]
+	for _, migration := range pending {
+		mock.ExpectBegin()
+		expectStatements(mock, migration.Up)
+		mock.ExpectExec(insertStr).
+			WithArgs(migration.Version, migration.Name, appliedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+
+	done, err := migrator.Up()
+	require.NoError(t, err)
+	require.Equal(t, pending, done)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestShouldStopAtFailedMigration(t *testing.T) {
+	migrator, mock := newTestMigrator(t)
+	expectApplied(mock)
+
+	migration := migrator.migrations[0]
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(migration.Up[0])).WillReturnError(errors.New("table exists"))
+	mock.ExpectRollback()
+
+	done, err := migrator.Up()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "0001_create_users")
+	require.Empty(t, done)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestShouldRollBackLatestMigration(t *testing.T) {
+	migrator, mock := newTestMigrator(t)
+	expectApplied(mock, 1, 2)
+
+	migration := migrator.migrations[1]
+	mock.ExpectBegin()
+	expectStatements(mock, migration.Down)
+	mock.ExpectExec(deleteStr).WithArgs(migration.Version).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	done, err := migrator.Down(1)
+	require.NoError(t, err)
+	require.Equal(t, []Migration{migration}, done)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestShouldNotRollBackUnknownMigration(t *testing.T) {
+	migrator, mock := newTestMigrator(t)
+	expectApplied(mock, 1, 99)
+
+	done, err := migrator.Down(1)
+	require.Error(t, err)
+	require.Empty(t, done)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestShouldReportStatus(t *testing.T) {
+	migrator, mock := newTestMigrator(t)
+	expectApplied(mock, 1)
+
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, len(migrator.migrations))
+	require.True(t, statuses[0].Applied)
+	require.Equal(t, appliedAt, statuses[0].AppliedAt)
+	require.False(t, statuses[1].Applied)
+	require.True(t, statuses[1].AppliedAt.IsZero())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
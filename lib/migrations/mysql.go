@@ -0,0 +1,77 @@
+package migrations
+
+// mysqlMigrations are the MySQL migrations. Never edit applied
+// migration, add a new one instead.
+var mysqlMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_users",
+		Up: []string{
+			"CREATE TABLE `users` (" +
+				"`id` bigint unsigned AUTO_INCREMENT," +
+				"`email` VARCHAR(40) NOT NULL," +
+				"`username` VARCHAR(40) NOT NULL," +
+				"`name` VARCHAR(40) NOT NULL," +
+				"`profile_img_url` VARCHAR(128) DEFAULT '/icon/defaultpic'," +
+				"`location` VARCHAR(40) DEFAULT 'Worldwide'," +
+				"`description` VARCHAR(256) DEFAULT 'The author tend to keep air of mystery of him/herself'," +
+				"`followers_count` int," +
+				"`following_count` int," +
+				"`twitter_name` VARCHAR(20)," +
+				"`facebook_name` VARCHAR(20)," +
+				"`created_at` DATETIME NULL," +
+				"`updated_at` DATETIME NULL," +
+				"`deleted_at` DATETIME NULL, " +
+				"PRIMARY KEY (`id`))",
+			"CREATE UNIQUE INDEX `uix_users_email` ON `users`(`email`)",
+			"CREATE UNIQUE INDEX `uix_users_username` ON `users`(`username`)",
+			"CREATE INDEX `idx_users_name` ON `users`(`name`)",
+			"CREATE INDEX `idx_users_deleted_at` ON `users`(`deleted_at`)",
+			"CREATE TABLE `followership` (" +
+				"`follower_id` bigint unsigned," +
+				"`followed_id` bigint unsigned, " +
+				"PRIMARY KEY (`follower_id`,`followed_id`))",
+		},
+		Down: []string{
+			"DROP TABLE `followership`",
+			"DROP TABLE `users`",
+		},
+	},
+	{
+		Version: 2,
+		Name:    "create_stories",
+		Up: []string{
+			"CREATE TABLE `stories` (" +
+				"`id` bigint unsigned AUTO_INCREMENT," +
+				"`author_id` bigint unsigned NOT NULL," +
+				"`title` VARCHAR(128) NOT NULL," +
+				"`subtitle` VARCHAR(256)," +
+				"`body` TEXT," +
+				"`slug` VARCHAR(160) NOT NULL," +
+				"`status` VARCHAR(16) NOT NULL DEFAULT 'draft'," +
+				"`reading_time` int," +
+				"`created_at` DATETIME NULL," +
+				"`updated_at` DATETIME NULL," +
+				"`published_at` DATETIME NULL," +
+				"`published_revision` int NOT NULL, " +
+				"PRIMARY KEY (`id`))",
+			"CREATE INDEX `idx_stories_author_id` ON `stories`(`author_id`)",
+			"CREATE INDEX `idx_stories_status` ON `stories`(`status`)",
+			"CREATE UNIQUE INDEX `uix_stories_slug` ON `stories`(`slug`)",
+			"CREATE TABLE `story_revisions` (" +
+				"`id` bigint unsigned AUTO_INCREMENT," +
+				"`story_id` bigint unsigned NOT NULL," +
+				"`number` int NOT NULL," +
+				"`title` VARCHAR(128) NOT NULL," +
+				"`subtitle` VARCHAR(256)," +
+				"`body` TEXT," +
+				"`created_at` DATETIME NULL, " +
+				"PRIMARY KEY (`id`))",
+			"CREATE UNIQUE INDEX `idx_story_revision_number` ON `story_revisions`(`story_id`, `number`)",
+		},
+		Down: []string{
+			"DROP TABLE `story_revisions`",
+			"DROP TABLE `stories`",
+		},
+	},
+}
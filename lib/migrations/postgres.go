@@ -0,0 +1,77 @@
+package migrations
+
+// postgresMigrations are the PostgreSQL migrations, same versions
+// as mysqlMigrations. Never edit applied migration, add a new one instead.
+var postgresMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_users",
+		Up: []string{
+			`CREATE TABLE "users" (` +
+				`"id" bigserial,` +
+				`"email" VARCHAR(40) NOT NULL,` +
+				`"username" VARCHAR(40) NOT NULL,` +
+				`"name" VARCHAR(40) NOT NULL,` +
+				`"profile_img_url" VARCHAR(128) DEFAULT '/icon/defaultpic',` +
+				`"location" VARCHAR(40) DEFAULT 'Worldwide',` +
+				`"description" VARCHAR(256) DEFAULT 'The author tend to keep air of mystery of him/herself',` +
+				`"followers_count" integer,` +
+				`"following_count" integer,` +
+				`"twitter_name" VARCHAR(20),` +
+				`"facebook_name" VARCHAR(20),` +
+				`"created_at" timestamp with time zone,` +
+				`"updated_at" timestamp with time zone,` +
+				`"deleted_at" timestamp with time zone, ` +
+				`PRIMARY KEY ("id"))`,
+			`CREATE UNIQUE INDEX "uix_users_email" ON "users"("email")`,
+			`CREATE UNIQUE INDEX "uix_users_username" ON "users"("username")`,
+			`CREATE INDEX "idx_users_name" ON "users"("name")`,
+			`CREATE INDEX "idx_users_deleted_at" ON "users"("deleted_at")`,
+			`CREATE TABLE "followership" (` +
+				`"follower_id" bigint,` +
+				`"followed_id" bigint, ` +
+				`PRIMARY KEY ("follower_id","followed_id"))`,
+		},
+		Down: []string{
+			`DROP TABLE "followership"`,
+			`DROP TABLE "users"`,
+		},
+	},
+	{
+		Version: 2,
+		Name:    "create_stories",
+		Up: []string{
+			`CREATE TABLE "stories" (` +
+				`"id" bigserial,` +
+				`"author_id" bigint NOT NULL,` +
+				`"title" VARCHAR(128) NOT NULL,` +
+				`"subtitle" VARCHAR(256),` +
+				`"body" TEXT,` +
+				`"slug" VARCHAR(160) NOT NULL,` +
+				`"status" VARCHAR(16) NOT NULL DEFAULT 'draft',` +
+				`"reading_time" integer,` +
+				`"created_at" timestamp with time zone,` +
+				`"updated_at" timestamp with time zone,` +
+				`"published_at" timestamp with time zone,` +
+				`"published_revision" integer NOT NULL, ` +
+				`PRIMARY KEY ("id"))`,
+			`CREATE INDEX "idx_stories_author_id" ON "stories"("author_id")`,
+			`CREATE INDEX "idx_stories_status" ON "stories"("status")`,
+			`CREATE UNIQUE INDEX "uix_stories_slug" ON "stories"("slug")`,
+			`CREATE TABLE "story_revisions" (` +
+				`"id" bigserial,` +
+				`"story_id" bigint NOT NULL,` +
+				`"number" integer NOT NULL,` +
+				`"title" VARCHAR(128) NOT NULL,` +
+				`"subtitle" VARCHAR(256),` +
+				`"body" TEXT,` +
+				`"created_at" timestamp with time zone, ` +
+				`PRIMARY KEY ("id"))`,
+			`CREATE UNIQUE INDEX "idx_story_revision_number" ON "story_revisions"("story_id", "number")`,
+		},
+		Down: []string{
+			`DROP TABLE "story_revisions"`,
+			`DROP TABLE "stories"`,
+		},
+	},
+}
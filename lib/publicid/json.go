@@ -0,0 +1,41 @@
+package publicid
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ID is an id serialized in json as its public id by the default
+// encoder. Use it as json field type in place of uint64 database key:
+//
+//	ID publicid.ID `json:"id,omitempty"`
+type ID uint64
+
+// String returns the public id
+func (id ID) String() string {
+	return Encode(uint64(id))
+}
+
+// MarshalJSON encodes id as json string of its public id
+func (id ID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(id.String())
+}
+
+// UnmarshalJSON decodes json string of public id, or null
+func (id *ID) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var publicID string
+	if err := json.Unmarshal(data, &publicID); err != nil {
+		return fmt.Errorf("%w: %s is not a json string", ErrInvalidID, data)
+	}
+
+	decoded, err := Decode(publicID)
+	if err != nil {
+		return err
+	}
+	*id = ID(decoded)
+	return nil
+}
@@ -0,0 +1,128 @@
+// Package publicid converts internal uint64 ids (database keys) to
+// short, URL-safe and non-sequential public ids, and back. Ids are
+// permuted by a Feistel network keyed by a secret salt, then encoded
+// in fixed width base62. This obfuscates, but does not encrypt, ids.
+package publicid
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Alphabet of base62 encoded public id, URL-safe without escaping
+const Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// Length of every public id, as 62^11 > 2^64
+const Length = 11
+
+const rounds = 4
+
+// ErrInvalidID returned when decoding malformed public id
+var ErrInvalidID = errors.New("publicid: invalid public id")
+
+// Encoder converts ids from and to public ids of a salt.
+// Safe for concurrent use.
+type Encoder struct {
+	keys [rounds]uint32
+}
+
+// NewEncoder creates new Encoder, public ids of different salts differ.
+// Salt MUST be kept secret and never change once ids are published.
+func NewEncoder(salt string) *Encoder {
+	var (
+		enc  = new(Encoder)
+		hash = sha256.Sum256([]byte(salt))
+	)
+	for i := range enc.keys {
+		enc.keys[i] = binary.BigEndian.Uint32(hash[i*4:])
+	}
+	return enc
+}
+
+// Encode converts id to its public id
+func (enc *Encoder) Encode(id uint64) string {
+	var (
+		n   = enc.permute(id)
+		buf [Length]byte
+	)
+	for i := Length - 1; i >= 0; i-- {
+		buf[i] = Alphabet[n%62]
+		n /= 62
+	}
+	return string(buf[:])
+}
+
+// Decode converts public id back to its id
+func (enc *Encoder) Decode(publicID string) (uint64, error) {
+	if len(publicID) != Length {
+		return 0, fmt.Errorf("%w: %q must be %d characters", ErrInvalidID, publicID, Length)
+	}
+
+	var n uint64
+	for i := 0; i < Length; i++ {
+		digit := strings.IndexByte(Alphabet, publicID[i])
+		if digit < 0 {
+			return 0, fmt.Errorf("%w: %q has invalid character %q", ErrInvalidID, publicID, publicID[i])
+		}
+		// n*62 + digit must not overflow uint64
+		if n > (^uint64(0)-uint64(digit))/62 {
+			return 0, fmt.Errorf("%w: %q out of range", ErrInvalidID, publicID)
+		}
+		n = n*62 + uint64(digit)
+	}
+	return enc.unpermute(n), nil
+}
+
+// permute is keyed bijection of uint64, balanced Feistel
+// network over 32 bit halves of n
+func (enc *Encoder) permute(n uint64) uint64 {
+	left, right := uint32(n>>32), uint32(n)
+	for _, key := range enc.keys {
+		left, right = right, left^round(right, key)
+	}
+	return uint64(left)<<32 | uint64(right)
+}
+
+// unpermute is the inverse of permute
+func (enc *Encoder) unpermute(n uint64) uint64 {
+	left, right := uint32(n>>32), uint32(n)
+	for i := rounds - 1; i >= 0; i-- {
+		left, right = right^round(left, enc.keys[i]), left
+	}
+	return uint64(left)<<32 | uint64(right)
+}
+
+// round is the Feistel round function, mixing half with key
+func round(half uint32, key uint32) uint32 {
+	x := half ^ key
+	x ^= x >> 16
+	x *= 0x85ebca6b
+	x ^= x >> 13
+	x *= 0xc2b2ae35
+	x ^= x >> 16
+	return x
+}
+
+// DefaultSalt is salt of the default encoder until SetDefault
+const DefaultSalt = "golumn-story-service"
+
+var defaultEncoder = NewEncoder(DefaultSalt)
+
+// SetDefault replaces encoder used by Encode, Decode and ID json
+// marshalling. It MUST be called on startup, before any id is encoded.
+func SetDefault(enc *Encoder) {
+	defaultEncoder = enc
+}
+
+// Encode converts id to its public id with default encoder
+func Encode(id uint64) string {
+	return defaultEncoder.Encode(id)
+}
+
+// Decode converts public id back to its id with default encoder
+func Decode(publicID string) (uint64, error) {
+	return defaultEncoder.Decode(publicID)
+}
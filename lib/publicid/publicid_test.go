@@ -0,0 +1,68 @@
+package publicid
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	enc := NewEncoder("test-salt")
+
+	ids := []uint64{0, 1, 2, 42, 1 << 32, 1<<63 + 7, math.MaxUint64}
+	for _, id := range ids {
+		publicID := enc.Encode(id)
+		require.Len(t, publicID, Length)
+		for _, char := range publicID {
+			require.True(t, strings.ContainsRune(Alphabet, char), "%q is not URL-safe", publicID)
+		}
+
+		decoded, err := enc.Decode(publicID)
+		require.NoError(t, err)
+		require.Equal(t, id, decoded)
+	}
+}
+
+func TestEncodeNonSequential(t *testing.T) {
+	enc := NewEncoder("test-salt")
+
+	first, second := enc.Encode(1), enc.Encode(2)
+	require.NotEqual(t, first[:Length-1], second[:Length-1], "sequential ids must not share prefix")
+	require.NotEqual(t, first, NewEncoder("other-salt").Encode(1), "salt must change public id")
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	enc := NewEncoder("test-salt")
+
+	invalids := []string{"", "short", "0123456789AB", "0123456789-", "zzzzzzzzzzz"}
+	for _, publicID := range invalids {
+		_, err := enc.Decode(publicID)
+		require.True(t, errors.Is(err, ErrInvalidID), "expect invalid %q, got %v", publicID, err)
+	}
+}
+
+func TestIDJSON(t *testing.T) {
+	type payload struct {
+		ID       ID `json:"id,omitempty"`
+		AuthorID ID `json:"author_id"`
+	}
+
+	data, err := json.Marshal(payload{ID: 7, AuthorID: 8})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"`+Encode(7)+`","author_id":"`+Encode(8)+`"}`, string(data))
+
+	var decoded payload
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, payload{ID: 7, AuthorID: 8}, decoded)
+
+	data, err = json.Marshal(payload{AuthorID: 8})
+	require.NoError(t, err)
+	require.NotContains(t, string(data), `"id"`, "zero id must be omitted")
+
+	require.Error(t, json.Unmarshal([]byte(`{"id":7}`), &decoded), "raw database key must be rejected")
+	require.Error(t, json.Unmarshal([]byte(`{"id":"not-an-id"}`), &decoded))
+}
@@ -0,0 +1,101 @@
+package random
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Bit layout of snowflake id, from the most significant bit:
+// 1 unused sign bit (id fits signed BIGINT), 41 bits milliseconds
+// since SnowflakeEpoch (~69 years), 10 bits node and 12 bits sequence.
+const (
+	SnowflakeTimeBits     = 41
+	SnowflakeNodeBits     = 10
+	SnowflakeSequenceBits = 12
+
+	MaxSnowflakeNode     = 1<<SnowflakeNodeBits - 1
+	maxSnowflakeSequence = 1<<SnowflakeSequenceBits - 1
+	maxSnowflakeMillis   = 1<<SnowflakeTimeBits - 1
+
+	snowflakeNodeShift = SnowflakeSequenceBits
+	snowflakeTimeShift = SnowflakeSequenceBits + SnowflakeNodeBits
+)
+
+// SnowflakeEpoch is the zero time of snowflake id timestamp
+var SnowflakeEpoch = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// SnowflakeGenerator implements UIntRandomizer that creates k-sortable
+// 64 bit id made of timestamp, node and sequence. Ids of a node strictly
+// increase, hence are appended at the end of InnoDB clustered index.
+// Safe for concurrent use.
+type SnowflakeGenerator struct {
+	mu       sync.Mutex
+	node     uint64
+	millis   int64
+	sequence uint64
+	now      func() time.Time
+}
+
+// NewSnowflakeGenerator creates new SnowflakeGenerator of given node.
+// Every process generating ids concurrently MUST have distinct node.
+func NewSnowflakeGenerator(node uint16) (*SnowflakeGenerator, error) {
+	if node > MaxSnowflakeNode {
+		return nil, fmt.Errorf("random: snowflake node %d out of range [0, %d]", node, MaxSnowflakeNode)
+	}
+	return &SnowflakeGenerator{node: uint64(node), now: time.Now}, nil
+}
+
+// Uint64 generates next snowflake id. When clock goes backward, it keeps
+// counting from the last timestamp instead of reusing past ids. When the
+// sequence of a millisecond is exhausted, it borrows the next millisecond.
+// Hence decoded timestamp can be slightly ahead of the wall clock.
+func (gen *SnowflakeGenerator) Uint64() uint64 {
+	gen.mu.Lock()
+	defer gen.mu.Unlock()
+
+	millis := gen.now().Sub(SnowflakeEpoch).Milliseconds()
+	switch {
+	case millis > gen.millis:
+		gen.millis = millis
+		gen.sequence = 0
+	case gen.sequence < maxSnowflakeSequence:
+		// same millisecond or clock rollback
+		gen.sequence++
+	default:
+		gen.millis++
+		gen.sequence = 0
+	}
+
+	return uint64(gen.millis&maxSnowflakeMillis)<<snowflakeTimeShift |
+		gen.node<<snowflakeNodeShift |
+		gen.sequence
+}
+
+// Uint32 generates lower 32 bits of next snowflake id. Unlike
+// Uint64, the result is neither ordered nor unique over time.
+func (gen *SnowflakeGenerator) Uint32() uint32 {
+	return uint32(gen.Uint64())
+}
+
+// Snowflake is decoded parts of snowflake id
+type Snowflake struct {
+	Time     time.Time
+	Node     uint16
+	Sequence uint16
+}
+
+// DecodeSnowflake decodes creation time, node and sequence of id
+func DecodeSnowflake(id uint64) Snowflake {
+	millis := int64(id >> snowflakeTimeShift & maxSnowflakeMillis)
+	return Snowflake{
+		Time:     SnowflakeEpoch.Add(time.Duration(millis) * time.Millisecond),
+		Node:     uint16(id >> snowflakeNodeShift & MaxSnowflakeNode),
+		Sequence: uint16(id & maxSnowflakeSequence),
+	}
+}
+
+// SnowflakeTime returns creation time of snowflake id
+func SnowflakeTime(id uint64) time.Time {
+	return DecodeSnowflake(id).Time
+}
@@ -0,0 +1,100 @@
+package random
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock returns settable time as time.Now
+type fakeClock struct{ current time.Time }
+
+func (clock *fakeClock) now() time.Time { return clock.current }
+
+func newTestSnowflake(t *testing.T, node uint16) (*SnowflakeGenerator, *fakeClock) {
+	gen, err := NewSnowflakeGenerator(node)
+	require.NoError(t, err)
+
+	clock := &fakeClock{current: time.Date(2020, time.May, 1, 12, 0, 0, 0, time.UTC)}
+	gen.now = clock.now
+	return gen, clock
+}
+
+func TestSnowflakeDecode(t *testing.T) {
+	gen, clock := newTestSnowflake(t, 42)
+
+	gen.Uint64()
+	id := gen.Uint64()
+	require.Equal(t, Snowflake{Time: clock.current, Node: 42, Sequence: 1}, DecodeSnowflake(id))
+	require.Equal(t, clock.current, SnowflakeTime(id))
+	require.Zero(t, id>>63, "sign bit must be unused")
+}
+
+func TestSnowflakeMonotonic(t *testing.T) {
+	gen, clock := newTestSnowflake(t, 1)
+
+	last := gen.Uint64()
+	steps := []time.Duration{0, time.Millisecond, 0, -time.Second, -time.Millisecond, 2 * time.Second}
+	for _, step := range steps {
+		clock.current = clock.current.Add(step)
+		id := gen.Uint64()
+		require.True(t, id > last, "id %d must be greater than %d after clock step %v", id, last, step)
+		last = id
+	}
+}
+
+func TestSnowflakeClockRollback(t *testing.T) {
+	gen, clock := newTestSnowflake(t, 1)
+
+	before := gen.Uint64()
+	clock.current = clock.current.Add(-time.Hour)
+	after := gen.Uint64()
+
+	require.True(t, after > before)
+	require.Equal(t, SnowflakeTime(before), SnowflakeTime(after), "rollback must not reuse past timestamp")
+}
+
+func TestSnowflakeSequenceOverflow(t *testing.T) {
+	gen, clock := newTestSnowflake(t, 1)
+
+	var id uint64
+	for i := 0; i <= maxSnowflakeSequence+1; i++ {
+		id = gen.Uint64()
+	}
+	decoded := DecodeSnowflake(id)
+	require.Equal(t, clock.current.Add(time.Millisecond), decoded.Time, "exhausted sequence borrows next millisecond")
+	require.Zero(t, decoded.Sequence)
+}
+
+func TestSnowflakeConcurrentUnique(t *testing.T) {
+	gen, err := NewSnowflakeGenerator(MaxSnowflakeNode)
+	require.NoError(t, err)
+
+	const workers, perWorker = 8, 2000
+	ids := make(chan uint64, workers*perWorker)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				ids <- gen.Uint64()
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[uint64]bool, workers*perWorker)
+	for id := range ids {
+		require.False(t, seen[id], "duplicate id %d", id)
+		seen[id] = true
+	}
+}
+
+func TestSnowflakeNodeOutOfRange(t *testing.T) {
+	_, err := NewSnowflakeGenerator(MaxSnowflakeNode + 1)
+	require.Error(t, err)
+}
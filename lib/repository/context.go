@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"unsafe"
+
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/jinzhu/gorm"
+)
+
+// sqlContextCommon is implemented by both *sql.DB and *sql.Tx
+type sqlContextCommon interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// sqlTxBeginner is implemented by *sql.DB
+type sqlTxBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// contextDB implements gorm.SQLCommon, it binds ctx to every
+// query and transaction issued to the underlying sql db/tx
+type contextDB struct {
+	ctx context.Context
+	db  sqlContextCommon
+}
+
+func (ctxDB *contextDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return ctxDB.db.ExecContext(ctxDB.ctx, query, args...)
+}
+
+func (ctxDB *contextDB) Prepare(query string) (*sql.Stmt, error) {
+	return ctxDB.db.PrepareContext(ctxDB.ctx, query)
+}
+
+func (ctxDB *contextDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return ctxDB.db.QueryContext(ctxDB.ctx, query, args...)
+}
+
+func (ctxDB *contextDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return ctxDB.db.QueryRowContext(ctxDB.ctx, query, args...)
+}
+
+// contextTxDB is contextDB of *sql.DB, it also implements
+// transaction beginner of gorm. contextDB of *sql.Tx must not,
+// as gorm would begin a (failing) nested transaction on it.
+type contextTxDB struct {
+	contextDB
+	beginner sqlTxBeginner
+}
+
+func (ctxDB *contextTxDB) Begin() (*sql.Tx, error) {
+	return ctxDB.beginner.BeginTx(ctxDB.ctx, nil)
+}
+
+// BeginTx starts transaction bound to ctxDB.ctx, the given ctx
+// is ignored as gorm always begins with context.Background()
+func (ctxDB *contextTxDB) BeginTx(_ context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return ctxDB.beginner.BeginTx(ctxDB.ctx, opts)
+}
+
+// WithContext returns new gorm session of db whose queries are bound
+// to ctx, the equivalent of gorm v2 db.WithContext(ctx). Like db.New(),
+// the session has no search conditions of db, but keeps its logger, log
+// mode, callbacks and Set values. db is returned as is when ctx can
+// never be done, e.g. context.Background().
+func WithContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if ctx == nil || ctx.Done() == nil {
+		return db
+	}
+
+	common, ok := db.CommonDB().(sqlContextCommon)
+	if !ok {
+		return db
+	}
+
+	var ctxCommon gorm.SQLCommon = &contextDB{ctx: ctx, db: common}
+	if beginner, ok := common.(sqlTxBeginner); ok {
+		ctxCommon = &contextTxDB{contextDB{ctx: ctx, db: common}, beginner}
+	}
+
+	ctxDB := db.New()
+	setCommonDB(ctxDB, ctxCommon)
+	return ctxDB
+}
+
+// sqlCommonType is type of the sql db field of gorm.DB
+var sqlCommonType = reflect.TypeOf((*gorm.SQLCommon)(nil)).Elem()
+
+// commonDBField is index of the sql db field of gorm.DB, checked at
+// init so that gorm upgrade renaming or retyping the unexported field
+// fails loudly rather than at query time. See TestGormVersionPinned.
+var commonDBField = func() []int {
+	field, ok := reflect.TypeOf(gorm.DB{}).FieldByName("db")
+	if !ok || field.Type != sqlCommonType {
+		panic("repository: gorm.DB has no db field of gorm.SQLCommon, WithContext does not support this gorm version")
+	}
+	return field.Index
+}()
+
+// setCommonDB replaces sql db of gorm session. Gorm v1 only does so
+// when beginning transaction, there is no exported way to set it.
+// The field is of checked type, so the write is as safe as gorm's own.
+func setCommonDB(db *gorm.DB, common gorm.SQLCommon) {
+	field := reflect.ValueOf(db).Elem().FieldByIndex(commonDBField)
+	reflect.NewAt(sqlCommonType, unsafe.Pointer(field.UnsafeAddr())).Elem().Set(reflect.ValueOf(common))
+	db.Dialect().SetDB(common)
+}
+
+// Transaction runs fn within new transaction of db. When db is already
+// bound to a transaction, e.g. by UnitOfWork, fn joins the ongoing one
+// as gorm cannot begin nested transaction.
+func Transaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if _, ok := db.CommonDB().(sqlTxBeginner); !ok {
+		return fn(db)
+	}
+	return db.Transaction(fn)
+}
+
+// Savepoint runs fn within savepoint of the transaction db is bound to,
+// so that the transaction survives error of fn. PostgreSQL aborts the
+// whole transaction on any error, rolling back to the savepoint undoes
+// only fn. fn runs as is when db is not bound to a transaction, or its
+// dialect is not PostgreSQL, as other databases only fail the statement.
+func Savepoint(db *gorm.DB, name string, fn func(tx *gorm.DB) error) error {
+	if _, ok := db.CommonDB().(sqlTxBeginner); ok || db.Dialect().GetName() != "postgres" {
+		return fn(db)
+	}
+
+	if err := db.Exec("SAVEPOINT " + name).Error; err != nil {
+		return err
+	}
+	if err := fn(db); err != nil {
+		// error of rollback is left to next statement of the transaction
+		db.Exec("ROLLBACK TO SAVEPOINT " + name)
+		return err
+	}
+	return db.Exec("RELEASE SAVEPOINT " + name).Error
+}
+
+// ContextError returns ctx.Err() in place of dbErr when ctx is done,
+// as drivers report cancelled query differently (context.Canceled,
+// driver.ErrBadConn, sql.ErrTxDone, pq "canceling statement"...).
+func ContextError(ctx context.Context, dbErr error) error {
+	if dbErr == nil || ctx == nil || ctx.Err() == nil {
+		return dbErr
+	}
+	return ctx.Err()
+}
+
+// ContextAppError converts ctx.Err() to domain.AppError,
+// returns nil when ctx is neither cancelled nor timed out
+func ContextAppError(ctx context.Context, message string) error {
+	switch ctx.Err() {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return domain.ErrRequestTimeout.Wrap(ctx.Err(), message)
+	default:
+		return domain.ErrRequestCancelled.Wrap(ctx.Err(), message)
+	}
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iqdf/golumn-story-service/domain"
+)
+
+func TestContextError(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	driverErr := errors.New("driver: bad connection")
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		dbErr  error
+		expect error
+		code   int
+	}{
+		{"live context", context.Background(), driverErr, driverErr, domain.InternalErrorCode},
+		{"cancelled context", cancelled, driverErr, context.Canceled, domain.RequestCancelledCode},
+		{"expired context", expired, driverErr, context.DeadlineExceeded, domain.RequestTimeoutCode},
+		{"no error", cancelled, nil, nil, 0},
+	}
+
+	errCvt := NewMySQLErrCvt()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ContextError(tt.ctx, tt.dbErr)
+			require.Equal(t, tt.expect, err)
+
+			appErr := errCvt.AppError(err, "context test")
+			if tt.expect == nil {
+				require.NoError(t, appErr)
+				return
+			}
+			require.Equal(t, tt.code, appErr.(*domain.AppError).Code())
+		})
+	}
+}
+
+func TestContextAppError(t *testing.T) {
+	require.NoError(t, ContextAppError(context.Background(), "live"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := ContextAppError(ctx, "cancelled")
+	require.Equal(t, domain.StatusClientClosedRequest, err.(*domain.AppError).HTTPCode())
+}
+
+// testLogger records gorm log lines
+type testLogger struct {
+	lines [][]interface{}
+}
+
+func (logger *testLogger) Print(values ...interface{}) {
+	logger.lines = append(logger.lines, values)
+}
+
+func TestWithContextKeepsSettings(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := gorm.Open("mysql", sqlDB)
+	require.NoError(t, err)
+
+	logger := new(testLogger)
+	db.SetLogger(logger)
+	db = db.LogMode(true).Set("gorm:table_options", "ENGINE=InnoDB")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctxDB := WithContext(ctx, db)
+	require.NotEqual(t, db, ctxDB)
+	_, ok := ctxDB.CommonDB().(*contextTxDB)
+	require.True(t, ok, "queries must be bound to ctx")
+
+	value, ok := ctxDB.Get("gorm:table_options")
+	require.True(t, ok, "Set values of db must be kept")
+	require.Equal(t, "ENGINE=InnoDB", value)
+
+	mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, ctxDB.Exec("UPDATE users SET name = ?", "name").Error)
+	require.NotEmpty(t, logger.lines, "logger and log mode of db must be kept")
+
+	cancel()
+	require.Error(t, ctxDB.Exec("UPDATE users SET name = ?", "name").Error)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestGormVersionPinned guards setCommonDB, which writes unexported
+// field of gorm.DB. Upgrading gorm requires reviewing it against the
+// gorm.DB of the new version, then updating this test.
+func TestGormVersionPinned(t *testing.T) {
+	gomod, err := ioutil.ReadFile("../../go.mod")
+	require.NoError(t, err)
+	require.Contains(t, string(gomod), "github.com/jinzhu/gorm v1.9.12\n",
+		"review setCommonDB against gorm.DB of the upgraded gorm")
+
+	field, ok := reflect.TypeOf(gorm.DB{}).FieldByName("db")
+	require.True(t, ok)
+	require.Equal(t, commonDBField, field.Index)
+}
@@ -1,10 +1,15 @@
 package repository
 
 import (
+	"context"
 	"regexp"
+	"strings"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/iqdf/golumn-story-service/domain"
 	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
 )
 
 // GormErrConverter ...
@@ -22,6 +27,8 @@ func (errCvt *GormErrConverter) checkNoRecordError(dbErr error) bool {
 }
 
 func (errCvt *GormErrConverter) checkTransactionError(dbErr error) bool {
+	// unit of work annotates transaction error with driver error
+	dbErr = errors.Cause(dbErr)
 	return (dbErr == gorm.ErrInvalidTransaction ||
 		dbErr == gorm.ErrCantStartTransaction)
 }
@@ -30,10 +37,20 @@ func (errCvt *GormErrConverter) checkSQLError(dbErr error) bool {
 	return dbErr == gorm.ErrInvalidSQL
 }
 
+func (errCvt *GormErrConverter) checkContextError(dbErr error) bool {
+	return dbErr == context.Canceled || dbErr == context.DeadlineExceeded
+}
+
 func (errCvt *GormErrConverter) checkUnaddressedError(dbErr error) bool {
 	return dbErr == gorm.ErrUnaddressable
 }
 
+// IsPrimaryKeyCollision reports whether dbErr is duplicate primary
+// key error. Gorm itself cannot tell, see dialect specific converter.
+func (errCvt *GormErrConverter) IsPrimaryKeyCollision(dbErr error) bool {
+	return false
+}
+
 // AppError converts Gorm based error to domain.AppError
 func (errCvt *GormErrConverter) AppError(dbErr error, message string) error {
 	if dbErr == nil {
@@ -48,6 +65,12 @@ func (errCvt *GormErrConverter) AppError(dbErr error, message string) error {
 	case errCvt.checkTransactionError(dbErr):
 		return domain.ErrInternalServer.Wrap(dbErr, message)
 
+	case errCvt.checkContextError(dbErr):
+		if dbErr == context.DeadlineExceeded {
+			return domain.ErrRequestTimeout.Wrap(dbErr, message)
+		}
+		return domain.ErrRequestCancelled.Wrap(dbErr, message)
+
 	case errCvt.checkSQLError(dbErr):
 		return domain.ErrInternalServer.Wrap(dbErr, message)
 
@@ -60,14 +83,32 @@ func (errCvt *GormErrConverter) AppError(dbErr error, message string) error {
 	}
 }
 
+// Lists of MySQL server error numbers converted to domain.AppError,
+// see MySQL Server Error Message Reference
+const (
+	MySQLDuplicateEntry  uint16 = 1062 // ER_DUP_ENTRY
+	MySQLDataTooLong     uint16 = 1406 // ER_DATA_TOO_LONG
+	MySQLRowIsReferenced uint16 = 1451 // ER_ROW_IS_REFERENCED_2
+	MySQLNoReferencedRow uint16 = 1452 // ER_NO_REFERENCED_ROW_2
+	MySQLColumnNull      uint16 = 1048 // ER_BAD_NULL_ERROR
+	MySQLLockDeadlock    uint16 = 1213 // ER_LOCK_DEADLOCK
+	MySQLLockWaitTimeout uint16 = 1205 // ER_LOCK_WAIT_TIMEOUT
+)
+
 var (
-	// RegexpMySQLDuplicate matches error string when
-	// MySQL DB throw integrity/duplicate error during insert operation
-	RegexpMySQLDuplicate = regexp.MustCompile(`^Error (?P<Code>\d{4}): Duplicate entry '(?P<Field>.+)' for key '(?P<Value>.+)'$`)
+	// RegexpMySQLDuplicate matches message of duplicate entry error.
+	// Key is index name, prefixed by table name since MySQL 8.0.19
+	RegexpMySQLDuplicate = regexp.MustCompile(`^Duplicate entry '(?P<Value>.*)' for key '(?P<Key>.+)'$`)
 
-	// RegexpMySQLDataLength matches error string when
-	// MySQL DB throw data length error during insert operation
-	RegexpMySQLDataLength = regexp.MustCompile(`Error (?P<Code>\d{4}): Data too long for column '(?P<Field>)' at row (?P<Row>.+)$`)
+	// RegexpMySQLDataLength matches message of data too long error
+	RegexpMySQLDataLength = regexp.MustCompile(`^Data too long for column '(?P<Field>[^']*)'`)
+
+	// RegexpMySQLColumnNull matches message of column null error
+	RegexpMySQLColumnNull = regexp.MustCompile(`^Column '(?P<Field>[^']*)' cannot be null`)
+
+	// RegexpMySQLForeignKey matches message of foreign key constraint
+	// error, Table is the referenced (parent) table
+	RegexpMySQLForeignKey = regexp.MustCompile("FOREIGN KEY \\(`(?P<Field>[^`]*)`\\) REFERENCES `(?P<Table>[^`]*)`")
 )
 
 // MySQLErrConverter ...
@@ -82,40 +123,110 @@ func NewMySQLErrCvt() *MySQLErrConverter {
 	}
 }
 
-func (errCvt *MySQLErrConverter) checkDuplicateError(dbErr error) bool {
-	return RegexpMySQLDuplicate.Match([]byte(dbErr.Error()))
+// asMySQLError finds *mysql.MySQLError in the chain of dbErr
+func asMySQLError(dbErr error) (*mysql.MySQLError, bool) {
+	var mysqlErr *mysql.MySQLError
+	ok := errors.As(dbErr, &mysqlErr)
+	return mysqlErr, ok
 }
 
-func (errCvt *MySQLErrConverter) checkDataLengthError(dbErr error) bool {
-	return RegexpMySQLDataLength.Match([]byte(dbErr.Error()))
+// IsPrimaryKeyCollision reports whether dbErr is duplicate entry
+// of PRIMARY key, as opposed to duplicate of other unique keys
+func (errCvt *MySQLErrConverter) IsPrimaryKeyCollision(dbErr error) bool {
+	mysqlErr, ok := asMySQLError(dbErr)
+	if !ok || mysqlErr.Number != MySQLDuplicateEntry {
+		return false
+	}
+	rexGroup := getParams(*RegexpMySQLDuplicate, mysqlErr.Message)
+	key := rexGroup["Key"]
+	return key == "PRIMARY" || strings.HasSuffix(key, ".PRIMARY")
 }
 
-// AppError ...
+// AppError converts Gorm and MySQL based error to domain.AppError.
+// MySQL reports column or key only in the message of *mysql.MySQLError,
+// which is matched once its error number is known.
 func (errCvt *MySQLErrConverter) AppError(dbErr error, message string) error {
 	if dbErr == nil {
 		return nil
 	}
 
-	if appErr := errCvt.GormErrConverter.AppError(dbErr, message); appErr != nil {
-		return appErr
+	mysqlErr, ok := asMySQLError(dbErr)
+	if !ok {
+		return errCvt.GormErrConverter.AppError(dbErr, message)
 	}
 
-	switch { // switch condition == true
-	case errCvt.checkDuplicateError(dbErr):
-		rexGroup := getParams(*RegexpMySQLDuplicate, dbErr.Error())
-		field, _ := rexGroup["Field"]
-		return domain.ErrBadParameters.WithMessagef("conflict duplicate %v", field)
+	switch mysqlErr.Number {
+	case MySQLDuplicateEntry:
+		rexGroup := getParams(*RegexpMySQLDuplicate, mysqlErr.Message)
+		field := mysqlKeyField(rexGroup["Key"])
+		return domain.ErrBadParameters.WithFieldMessagef(field, domain.DetailDuplicate, "conflict duplicate %v", rexGroup["Value"])
+
+	case MySQLDataTooLong:
+		field := getParams(*RegexpMySQLDataLength, mysqlErr.Message)["Field"]
+		return domain.ErrBadParameters.WithFieldMessagef(field, domain.DetailMaxLength, "data too long for %v field", field)
+
+	case MySQLColumnNull:
+		field := getParams(*RegexpMySQLColumnNull, mysqlErr.Message)["Field"]
+		return domain.ErrBadParameters.WithFieldMessagef(field, domain.DetailRequired, "missing required %v field", field)
+
+	case MySQLNoReferencedRow:
+		rexGroup := getParams(*RegexpMySQLForeignKey, mysqlErr.Message)
+		return domain.ErrUnknownResource.WithFieldMessagef(rexGroup["Field"], domain.DetailNotFound,
+			"referenced %v not found", rexGroup["Table"])
 
-	case errCvt.checkDataLengthError(dbErr):
-		rexGroup := getParams(*RegexpMySQLDataLength, dbErr.Error())
-		field, _ := rexGroup["Field"]
-		return domain.ErrBadParameters.WithMessagef("data too long for %v field", field)
+	case MySQLRowIsReferenced:
+		field := getParams(*RegexpMySQLForeignKey, mysqlErr.Message)["Field"]
+		return domain.ErrResourceConflict.WithFieldMessagef(field, domain.DetailReferenced,
+			"still referenced by %v", field)
+
+	case MySQLLockDeadlock, MySQLLockWaitTimeout:
+		return domain.ErrTransactionConflict.Wrap(dbErr, message)
 
 	default:
 		return domain.ErrInternalServer.Wrap(dbErr, message)
 	}
 }
 
+// primaryKeyFields maps table to field of its primary key collision
+var primaryKeyFields = map[string]string{}
+
+// RegisterPrimaryKeyField sets field reported for duplicate primary key
+// of table, which is "id" by default. Composite key, e.g. of join table,
+// is better reported as the field identifying the duplicate to client.
+// It is not safe for concurrent use, call it on init.
+func RegisterPrimaryKeyField(table string, field string) {
+	primaryKeyFields[table] = field
+}
+
+// primaryKeyField returns field of primary key of table
+func primaryKeyField(table string) string {
+	if field, ok := primaryKeyFields[table]; ok {
+		return field
+	}
+	return "id"
+}
+
+// mysqlKeyField returns the column of unique key, assuming
+// gorm naming "uix_<table>_<column>" of single word column.
+// Key named otherwise, e.g. composite index, is returned as is.
+// Primary key is mapped per table, see RegisterPrimaryKeyField,
+// provided the key is prefixed with table name.
+func mysqlKeyField(key string) string {
+	// MySQL 8.0.19 onward prefixes key with table name
+	table := ""
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		table, key = key[:i], key[i+1:]
+	}
+	switch {
+	case key == "PRIMARY":
+		return primaryKeyField(table)
+	case strings.HasPrefix(key, "uix_"):
+		return key[strings.LastIndexByte(key, '_')+1:]
+	default:
+		return key
+	}
+}
+
 func getParams(regEx regexp.Regexp, str string) (paramsMap map[string]string) {
 	match := regEx.FindStringSubmatch(str)
 	paramsMap = make(map[string]string)
@@ -127,3 +238,92 @@ func getParams(regEx regexp.Regexp, str string) (paramsMap map[string]string) {
 	}
 	return
 }
+
+// Lists of PostgreSQL error codes (SQLSTATE) converted to
+// domain.ErrBadParameters, see PostgreSQL Appendix A.
+const (
+	PostgresUniqueViolation           pq.ErrorCode = "23505"
+	PostgresStringDataRightTruncation pq.ErrorCode = "22001"
+	PostgresNotNullViolation          pq.ErrorCode = "23502"
+)
+
+// Lists of PostgreSQL error codes (SQLSTATE) of
+// transaction worth retrying, see PostgreSQL Appendix A.
+const (
+	PostgresSerializationFailure pq.ErrorCode = "40001"
+	PostgresDeadlockDetected     pq.ErrorCode = "40P01"
+)
+
+// RegexpPostgresKeyDetail matches error detail when PostgreSQL
+// DB throw unique violation error during insert/update operation
+var RegexpPostgresKeyDetail = regexp.MustCompile(`^Key \((?P<Field>.+?)\)=\((?P<Value>.*)\) already exists\.?$`)
+
+// PostgresErrConverter ...
+type PostgresErrConverter struct {
+	GormErrConverter
+}
+
+// NewPostgresErrCvt ...
+func NewPostgresErrCvt() *PostgresErrConverter {
+	return &PostgresErrConverter{
+		GormErrConverter: *NewGormErrCvt("postgres"),
+	}
+}
+
+// asPostgresError finds *pq.Error in the chain of dbErr
+func asPostgresError(dbErr error) (*pq.Error, bool) {
+	var pqErr *pq.Error
+	ok := errors.As(dbErr, &pqErr)
+	return pqErr, ok
+}
+
+// IsPrimaryKeyCollision reports whether dbErr is unique violation of
+// primary key constraint, named "<table>_pkey" by PostgreSQL default
+func (errCvt *PostgresErrConverter) IsPrimaryKeyCollision(dbErr error) bool {
+	pqErr, ok := asPostgresError(dbErr)
+	return ok && pqErr.Code == PostgresUniqueViolation &&
+		strings.HasSuffix(pqErr.Constraint, "_pkey")
+}
+
+// AppError converts Gorm and PostgreSQL based error to domain.AppError.
+// Unlike MySQL, PostgreSQL reports the SQLSTATE code and offending
+// column in *pq.Error fields, hence no need to match error string.
+func (errCvt *PostgresErrConverter) AppError(dbErr error, message string) error {
+	if dbErr == nil {
+		return nil
+	}
+
+	pqErr, ok := asPostgresError(dbErr)
+	if !ok {
+		return errCvt.GormErrConverter.AppError(dbErr, message)
+	}
+
+	switch pqErr.Code {
+	case PostgresUniqueViolation:
+		rexGroup := getParams(*RegexpPostgresKeyDetail, pqErr.Detail)
+		field, ok := rexGroup["Field"]
+		if !ok {
+			field = pqErr.Constraint
+		}
+		if table := strings.TrimSuffix(pqErr.Constraint, "_pkey"); table != pqErr.Constraint {
+			if registered, ok := primaryKeyFields[table]; ok {
+				field = registered
+			}
+		}
+		return domain.ErrBadParameters.WithFieldMessagef(field, domain.DetailDuplicate, "conflict duplicate %v", field)
+
+	case PostgresStringDataRightTruncation:
+		// PostgreSQL does not tell the column of too long value
+		// for most statements, pqErr.Column is likely empty
+		return domain.ErrBadParameters.WithFieldMessagef(pqErr.Column, domain.DetailMaxLength, "data too long for %v field", pqErr.Column)
+
+	case PostgresNotNullViolation:
+		return domain.ErrBadParameters.WithFieldMessagef(pqErr.Column, domain.DetailRequired, "missing required %v field", pqErr.Column)
+
+	case PostgresSerializationFailure, PostgresDeadlockDetected:
+		return domain.ErrTransactionConflict.Wrap(dbErr, message)
+
+	default:
+		return domain.ErrInternalServer.Wrap(dbErr, message)
+	}
+}
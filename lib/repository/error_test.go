@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iqdf/golumn-story-service/domain"
+)
+
+func TestPostgresErrConverter(t *testing.T) {
+	RegisterPrimaryKeyField("followership", "username")
+
+	tests := []struct {
+		name    string
+		dbErr   error
+		code    int
+		message string
+		detail  domain.ErrorDetail
+	}{
+		{
+			name: "unique violation",
+			dbErr: &pq.Error{
+				Code:       "23505",
+				Detail:     "Key (username)=(UserZero) already exists.",
+				Constraint: "uix_users_username",
+			},
+			code:    domain.InvalidParamCode,
+			message: "conflict duplicate username",
+			detail:  domain.ErrorDetail{Field: "username", Code: domain.DetailDuplicate, Message: "conflict duplicate username"},
+		},
+		{
+			name:    "unique violation without detail",
+			dbErr:   &pq.Error{Code: "23505", Constraint: "users_pkey"},
+			code:    domain.InvalidParamCode,
+			message: "conflict duplicate users_pkey",
+		},
+		{
+			name: "unique violation of registered primary key",
+			dbErr: &pq.Error{
+				Code:       "23505",
+				Detail:     "Key (follower_id, followed_id)=(1, 2) already exists.",
+				Constraint: "followership_pkey",
+			},
+			code:    domain.InvalidParamCode,
+			message: "conflict duplicate username",
+			detail:  domain.ErrorDetail{Field: "username", Code: domain.DetailDuplicate, Message: "conflict duplicate username"},
+		},
+		{
+			name:    "string data right truncation",
+			dbErr:   &pq.Error{Code: "22001", Column: "name"},
+			code:    domain.InvalidParamCode,
+			message: "data too long for name field",
+			detail:  domain.ErrorDetail{Field: "name", Code: domain.DetailMaxLength, Message: "data too long for name field"},
+		},
+		{
+			name:    "not null violation",
+			dbErr:   &pq.Error{Code: "23502", Column: "email"},
+			code:    domain.InvalidParamCode,
+			message: "missing required email field",
+			detail:  domain.ErrorDetail{Field: "email", Code: domain.DetailRequired, Message: "missing required email field"},
+		},
+		{
+			name:    "wrapped not null violation",
+			dbErr:   fmt.Errorf("insert user: %w", &pq.Error{Code: "23502", Column: "email"}),
+			code:    domain.InvalidParamCode,
+			message: "missing required email field",
+			detail:  domain.ErrorDetail{Field: "email", Code: domain.DetailRequired, Message: "missing required email field"},
+		},
+		{
+			name:  "deadlock detected",
+			dbErr: &pq.Error{Code: "40P01"},
+			code:  domain.TransactionConflictCode,
+		},
+		{
+			name:  "unhandled postgres error",
+			dbErr: &pq.Error{Code: "42P01"},
+			code:  domain.InternalErrorCode,
+		},
+		{
+			name:  "gorm record not found",
+			dbErr: gorm.ErrRecordNotFound,
+			code:  domain.UnknownResourceCode,
+		},
+		{
+			name:  "unknown error",
+			dbErr: errors.New("connection refused"),
+			code:  domain.InternalErrorCode,
+		},
+	}
+
+	errCvt := NewPostgresErrCvt()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := errCvt.AppError(tt.dbErr, "test")
+			appErr, ok := err.(*domain.AppError)
+			require.True(t, ok)
+			require.Equal(t, tt.code, appErr.Code())
+			require.Contains(t, appErr.Message(), tt.message)
+			if tt.detail.Field != "" {
+				require.Equal(t, []domain.ErrorDetail{tt.detail}, appErr.Details)
+			}
+		})
+	}
+
+	require.NoError(t, errCvt.AppError(nil, "test"))
+}
+
+func TestMySQLErrConverter(t *testing.T) {
+	RegisterPrimaryKeyField("followership", "username")
+
+	tests := []struct {
+		name   string
+		dbErr  error
+		code   int
+		detail *domain.ErrorDetail
+	}{
+		{
+			name:   "duplicate unique key",
+			dbErr:  &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'UserZero' for key 'uix_users_username'"},
+			code:   domain.InvalidParamCode,
+			detail: &domain.ErrorDetail{Field: "username", Code: domain.DetailDuplicate, Message: "conflict duplicate UserZero"},
+		},
+		{
+			name:   "duplicate table qualified key",
+			dbErr:  &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@example.com' for key 'users.uix_users_email'"},
+			code:   domain.InvalidParamCode,
+			detail: &domain.ErrorDetail{Field: "email", Code: domain.DetailDuplicate, Message: "conflict duplicate a@example.com"},
+		},
+		{
+			name:   "duplicate primary key",
+			dbErr:  &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '4242' for key 'PRIMARY'"},
+			code:   domain.InvalidParamCode,
+			detail: &domain.ErrorDetail{Field: "id", Code: domain.DetailDuplicate, Message: "conflict duplicate 4242"},
+		},
+		{
+			name:   "duplicate registered primary key",
+			dbErr:  &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1-2' for key 'followership.PRIMARY'"},
+			code:   domain.InvalidParamCode,
+			detail: &domain.ErrorDetail{Field: "username", Code: domain.DetailDuplicate, Message: "conflict duplicate 1-2"},
+		},
+		{
+			name:   "duplicate composite key",
+			dbErr:  &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1-2' for key 'idx_story_revision_number'"},
+			code:   domain.InvalidParamCode,
+			detail: &domain.ErrorDetail{Field: "idx_story_revision_number", Code: domain.DetailDuplicate, Message: "conflict duplicate 1-2"},
+		},
+		{
+			name:   "data too long",
+			dbErr:  &mysql.MySQLError{Number: 1406, Message: "Data too long for column 'name' at row 1"},
+			code:   domain.InvalidParamCode,
+			detail: &domain.ErrorDetail{Field: "name", Code: domain.DetailMaxLength, Message: "data too long for name field"},
+		},
+		{
+			name:   "column null",
+			dbErr:  &mysql.MySQLError{Number: 1048, Message: "Column 'email' cannot be null"},
+			code:   domain.InvalidParamCode,
+			detail: &domain.ErrorDetail{Field: "email", Code: domain.DetailRequired, Message: "missing required email field"},
+		},
+		{
+			name: "no referenced row",
+			dbErr: &mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails " +
+				"(`golumn`.`followerships`, CONSTRAINT `fk_followed` FOREIGN KEY (`followed_id`) REFERENCES `users` (`id`))"},
+			code:   domain.UnknownResourceCode,
+			detail: &domain.ErrorDetail{Field: "followed_id", Code: domain.DetailNotFound, Message: "referenced users not found"},
+		},
+		{
+			name: "row is referenced",
+			dbErr: &mysql.MySQLError{Number: 1451, Message: "Cannot delete or update a parent row: a foreign key constraint fails " +
+				"(`golumn`.`stories`, CONSTRAINT `fk_author` FOREIGN KEY (`author_id`) REFERENCES `users` (`id`))"},
+			code:   domain.ResourceConflictCode,
+			detail: &domain.ErrorDetail{Field: "author_id", Code: domain.DetailReferenced, Message: "still referenced by author_id"},
+		},
+		{
+			name:  "deadlock",
+			dbErr: &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock; try restarting transaction"},
+			code:  domain.TransactionConflictCode,
+		},
+		{
+			name:  "lock wait timeout",
+			dbErr: &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded; try restarting transaction"},
+			code:  domain.TransactionConflictCode,
+		},
+		{
+			name:  "unhandled mysql error",
+			dbErr: &mysql.MySQLError{Number: 1146, Message: "Table 'golumn.users' doesn't exist"},
+			code:  domain.InternalErrorCode,
+		},
+		{
+			name:  "string matching error is not converted",
+			dbErr: errors.New("Error 1062: Duplicate entry 'UserZero' for key 'uix_users_username'"),
+			code:  domain.InternalErrorCode,
+		},
+		{
+			name:  "unknown error",
+			dbErr: errors.New("connection refused"),
+			code:  domain.InternalErrorCode,
+		},
+	}
+
+	errCvt := NewMySQLErrCvt()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := errCvt.AppError(tt.dbErr, "test")
+			appErr, ok := err.(*domain.AppError)
+			require.True(t, ok)
+			require.Equal(t, tt.code, appErr.Code())
+			if tt.detail == nil {
+				require.Empty(t, appErr.Details)
+				return
+			}
+			require.Equal(t, []domain.ErrorDetail{*tt.detail}, appErr.Details)
+			require.Contains(t, appErr.Message(), tt.detail.Message)
+		})
+	}
+}
+
+func TestIsPrimaryKeyCollision(t *testing.T) {
+	tests := []struct {
+		name      string
+		errCvt    interface{ IsPrimaryKeyCollision(error) bool }
+		dbErr     error
+		collision bool
+	}{
+		{"mysql primary", NewMySQLErrCvt(),
+			&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '4242' for key 'PRIMARY'"}, true},
+		{"mysql 8 primary", NewMySQLErrCvt(),
+			&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '4242' for key 'users.PRIMARY'"}, true},
+		{"mysql unique username", NewMySQLErrCvt(),
+			&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'UserZero' for key 'uix_users_username'"}, false},
+		{"mysql other error", NewMySQLErrCvt(), errors.New("connection refused"), false},
+		{"mysql nil", NewMySQLErrCvt(), nil, false},
+		{"postgres primary", NewPostgresErrCvt(),
+			&pq.Error{Code: "23505", Detail: "Key (id)=(4242) already exists.", Constraint: "users_pkey"}, true},
+		{"postgres unique username", NewPostgresErrCvt(),
+			&pq.Error{Code: "23505", Constraint: "uix_users_username"}, false},
+		{"postgres other error", NewPostgresErrCvt(), &pq.Error{Code: "23502", Constraint: "users_pkey"}, false},
+		{"postgres wrapped primary", NewPostgresErrCvt(),
+			fmt.Errorf("insert user: %w", &pq.Error{Code: "23505", Constraint: "users_pkey"}), true},
+		{"gorm", NewGormErrCvt("sqlite3"), gorm.ErrRecordNotFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.collision, tt.errCvt.IsPrimaryKeyCollision(tt.dbErr))
+		})
+	}
+}
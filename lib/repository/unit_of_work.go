@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// Default retry policy of GormUnitOfWork on deadlock
+const (
+	DefaultMaxRetries   = 3
+	DefaultRetryBackoff = 20 * time.Millisecond
+)
+
+// ErrorConverter converts db error to domain.AppError,
+// e.g. MySQLErrConverter and PostgresErrConverter
+type ErrorConverter interface {
+	AppError(error, string) error
+}
+
+// RepositoryFactory creates repositories bound to transaction tx
+type RepositoryFactory func(tx *gorm.DB) domain.Repositories
+
+// GormUnitOfWork implements domain.UnitOfWork on top of gorm
+// transaction. Repositories created by Factory from the transaction
+// join it instead of beginning their own (see Transaction).
+type GormUnitOfWork struct {
+	DB           *gorm.DB
+	Factory      RepositoryFactory
+	ErrCvt       ErrorConverter
+	MaxRetries   int
+	RetryBackoff time.Duration
+}
+
+// NewGormUnitOfWork creates new GormUnitOfWork with default retry policy
+func NewGormUnitOfWork(db *gorm.DB, factory RepositoryFactory, errCvt ErrorConverter) *GormUnitOfWork {
+	return &GormUnitOfWork{
+		DB:           db,
+		Factory:      factory,
+		ErrCvt:       errCvt,
+		MaxRetries:   DefaultMaxRetries,
+		RetryBackoff: DefaultRetryBackoff,
+	}
+}
+
+// Do runs fn within single transaction bound to ctx. The whole
+// transaction is re-run, up to MaxRetries times with linear backoff,
+// when it is rolled back by db to break deadlock.
+func (uow *GormUnitOfWork) Do(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = uow.run(ctx, fn)
+		if err == nil || !isDeadlock(err) || attempt >= uow.MaxRetries {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ContextAppError(ctx, "unitofwork: retry deadlocked transaction aborted")
+		case <-time.After(time.Duration(attempt+1) * uow.RetryBackoff):
+		}
+	}
+	return uow.appError(ctx, err)
+}
+
+// run runs fn within single transaction, rolled back when
+// fn fails or panics, otherwise committed
+func (uow *GormUnitOfWork) run(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	tx := uow.DB.BeginTx(ctx, nil)
+	if err := tx.Error; err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return errors.Wrap(gorm.ErrCantStartTransaction, err.Error())
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			tx.Rollback()
+		}
+	}()
+
+	if err := fn(uow.Factory(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// appError returns app error of fn as is, and converts
+// error of the transaction itself to domain.AppError
+func (uow *GormUnitOfWork) appError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if appErr, ok := domain.AsAppError(err); ok {
+		return appErr
+	}
+	return uow.ErrCvt.AppError(ContextError(ctx, err), "unitofwork: transaction fail")
+}
+
+// isDeadlock reports whether err, or any error in its chain,
+// is MySQL/PostgreSQL deadlock or serialization failure
+func isDeadlock(err error) bool {
+	if mysqlErr, ok := asMySQLError(err); ok {
+		return mysqlErr.Number == MySQLLockDeadlock ||
+			mysqlErr.Number == MySQLLockWaitTimeout
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == PostgresDeadlockDetected ||
+			pqErr.Code == PostgresSerializationFailure
+	}
+	return false
+}
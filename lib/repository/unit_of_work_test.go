@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iqdf/golumn-story-service/domain"
+)
+
+var (
+	execStr     = regexp.QuoteMeta("UPDATE users SET followers_count = followers_count + 1")
+	errDeadlock = &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock; try restarting transaction"}
+)
+
+// newTestUnitOfWork creates unit of work on sqlmock db, whose fn
+// executes execStr within transaction tx given to the factory
+func newTestUnitOfWork(t *testing.T) (*GormUnitOfWork, sqlmock.Sqlmock, func(repos domain.Repositories) error) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := gorm.Open("mysql", sqlDB)
+	require.NoError(t, err)
+
+	var tx *gorm.DB
+	factory := func(txDB *gorm.DB) domain.Repositories {
+		tx = txDB
+		return domain.Repositories{}
+	}
+	fn := func(repos domain.Repositories) error {
+		return Transaction(tx, func(tx *gorm.DB) error {
+			return tx.Exec("UPDATE users SET followers_count = followers_count + 1").Error
+		})
+	}
+
+	uow := NewGormUnitOfWork(db, factory, NewMySQLErrCvt())
+	uow.RetryBackoff = 0
+	return uow, mock, fn
+}
+
+func TestUnitOfWorkCommit(t *testing.T) {
+	uow, mock, fn := newTestUnitOfWork(t)
+
+	// nested Transaction joins the unit of work
+	mock.ExpectBegin()
+	mock.ExpectExec(execStr).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, uow.Do(context.Background(), fn))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnitOfWorkRollbackAppError(t *testing.T) {
+	uow, mock, _ := newTestUnitOfWork(t)
+	appErr := domain.ErrUnknownResource.WithMessage("not found")
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err := uow.Do(context.Background(), func(repos domain.Repositories) error {
+		return appErr
+	})
+	require.Equal(t, appErr, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnitOfWorkRetryDeadlock(t *testing.T) {
+	uow, mock, fn := newTestUnitOfWork(t)
+
+	for _, dbErr := range []error{errDeadlock, &pq.Error{Code: PostgresDeadlockDetected}} {
+		mock.ExpectBegin()
+		mock.ExpectExec(execStr).WillReturnError(dbErr)
+		mock.ExpectRollback()
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(execStr).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, uow.Do(context.Background(), fn))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnitOfWorkGiveUpDeadlock(t *testing.T) {
+	uow, mock, fn := newTestUnitOfWork(t)
+	uow.MaxRetries = 1
+
+	for i := 0; i <= uow.MaxRetries; i++ {
+		mock.ExpectBegin()
+		mock.ExpectExec(execStr).WillReturnError(errDeadlock)
+		mock.ExpectRollback()
+	}
+
+	err := uow.Do(context.Background(), fn)
+	require.True(t, errors.Is(err, domain.ErrTransactionConflict), "expect retryable conflict, got %v", err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnitOfWorkBeginFail(t *testing.T) {
+	uow, mock, fn := newTestUnitOfWork(t)
+
+	mock.ExpectBegin().WillReturnError(errors.New("driver: bad connection"))
+
+	err := uow.Do(context.Background(), fn)
+	require.Error(t, err)
+	require.Equal(t, domain.InternalErrorCode, err.(*domain.AppError).Code())
+	require.True(t, NewGormErrCvt("mysql").checkTransactionError(err.(*domain.AppError).Cause()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnitOfWorkCancelled(t *testing.T) {
+	uow, mock, fn := newTestUnitOfWork(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := uow.Do(ctx, fn)
+	require.Error(t, err)
+	require.Equal(t, domain.RequestCancelledCode, err.(*domain.AppError).Code())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
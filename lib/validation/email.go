@@ -0,0 +1,116 @@
+package validation
+
+import (
+	"strings"
+)
+
+// Limits of email address parts, RFC 3696 - Section 3 (errata 1690)
+const (
+	MaxEmailLocalLength  = 64
+	MaxEmailDomainLength = 255
+	MaxEmailLength       = 254
+	maxDomainLabelLength = 63
+)
+
+// atextSpecials are the non-alphanumeric characters allowed
+// unquoted in local part of email address
+const atextSpecials = "!#$%&'*+-/=?^_`{|}~"
+
+// IsEmail reports whether address is an email address as specified
+// by RFC 3696 - Section 3: local part of unquoted characters separated
+// by single periods, or a quoted string, then "@" and a fully
+// qualified domain name whose top level label is not all-numeric.
+// Address literals (e.g. user@[10.0.0.1]) are not accepted.
+func IsEmail(address string) bool {
+	if len(address) > MaxEmailLength {
+		return false
+	}
+	at := strings.LastIndexByte(address, '@')
+	if at < 0 {
+		return false
+	}
+	return isEmailLocal(address[:at]) && isEmailDomain(address[at+1:])
+}
+
+func isEmailLocal(local string) bool {
+	if len(local) == 0 || len(local) > MaxEmailLocalLength {
+		return false
+	}
+	if local[0] == '"' {
+		return isQuotedLocal(local)
+	}
+	for _, atom := range strings.Split(local, ".") {
+		// period must not start, end or repeat in local part
+		if len(atom) == 0 {
+			return false
+		}
+		for i := 0; i < len(atom); i++ {
+			if !isAlphanumeric(atom[i]) && strings.IndexByte(atextSpecials, atom[i]) < 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isQuotedLocal reports whether local is a quoted string, in which
+// any printable ASCII is allowed, but quote and backslash must be
+// escaped by a backslash
+func isQuotedLocal(local string) bool {
+	if len(local) < 2 || local[len(local)-1] != '"' {
+		return false
+	}
+	quoted := local[1 : len(local)-1]
+	for i := 0; i < len(quoted); i++ {
+		char := quoted[i]
+		switch {
+		case char < ' ' || char > '~':
+			return false
+		case char == '\\':
+			i++
+			if i == len(quoted) {
+				return false
+			}
+		case char == '"':
+			return false
+		}
+	}
+	return true
+}
+
+func isEmailDomain(domain string) bool {
+	if len(domain) == 0 || len(domain) > MaxEmailDomainLength {
+		return false
+	}
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > maxDomainLabelLength {
+			return false
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			if !isAlphanumeric(label[i]) && label[i] != '-' {
+				return false
+			}
+		}
+	}
+	return !isNumeric(labels[len(labels)-1])
+}
+
+func isAlphanumeric(char byte) bool {
+	return ('a' <= char && char <= 'z') || ('A' <= char && char <= 'Z') || ('0' <= char && char <= '9')
+}
+
+func isNumeric(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
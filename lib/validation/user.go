@@ -0,0 +1,63 @@
+package validation
+
+import (
+	"regexp"
+
+	"github.com/iqdf/golumn-story-service/domain"
+)
+
+// Length constraints of user fields, upper bounds
+// match the columns of user repositories
+const (
+	MaxEmailColumnLength  = 40
+	MinUsernameLength     = 5
+	MaxUsernameLength     = 40
+	MinNameLength         = 2
+	MaxNameLength         = 40
+	MaxProfileImgURLength = 128
+	MaxLocationLength     = 40
+	MaxDescriptionLength  = 256
+)
+
+// usernamePattern keeps username usable in URL path "/@username"
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]*$`)
+
+// ValidateUser checks fields of user to be created,
+// returns domain.ErrBadParameters listing invalid fields
+func ValidateUser(user domain.User) error {
+	v := New()
+	checkEmail(v, user.Email)
+	checkUsername(v, user.Username)
+	v.Required("name", user.Name)
+	v.Length("name", user.Name, MinNameLength, MaxNameLength)
+	v.Length("profile_img_url", user.ProfileImgURL, 0, MaxProfileImgURLength)
+	v.Length("location", user.Location, 0, MaxLocationLength)
+	v.Length("description", user.Description, 0, MaxDescriptionLength)
+	return v.Err()
+}
+
+// ValidateEmail checks email address of user
+func ValidateEmail(email string) error {
+	v := New()
+	checkEmail(v, email)
+	return v.Err()
+}
+
+// ValidateUsername checks username of user
+func ValidateUsername(username string) error {
+	v := New()
+	checkUsername(v, username)
+	return v.Err()
+}
+
+func checkEmail(v *Validator, email string) {
+	v.Required("email", email)
+	v.Length("email", email, 0, MaxEmailColumnLength)
+	v.Email("email", email)
+}
+
+func checkUsername(v *Validator, username string) {
+	v.Required("username", username)
+	v.Length("username", username, MinUsernameLength, MaxUsernameLength)
+	v.Pattern("username", username, usernamePattern, "contain only letters, digits, '.', '_' or '-'")
+}
@@ -0,0 +1,91 @@
+// Package validation checks client input against field rules,
+// and reports every invalid field at once within a single
+// domain.ErrBadParameters (see domain.AppError.Details).
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/iqdf/golumn-story-service/domain"
+)
+
+// Lists of validation rules, reported as domain.ErrorDetail.Code
+const (
+	RuleRequired  = domain.DetailRequired
+	RuleMinLength = domain.DetailMinLength
+	RuleMaxLength = domain.DetailMaxLength
+	RuleEmail     = "email"
+	RulePattern   = "pattern"
+)
+
+// Validator accumulates details of invalid fields. Rules of a field
+// are checked in order until the first violation, so each invalid
+// field is reported once, by its most fundamental violated rule.
+type Validator struct {
+	details []domain.ErrorDetail
+}
+
+// New creates new Validator without any invalid field
+func New() *Validator {
+	return &Validator{}
+}
+
+// Err returns domain.ErrBadParameters detailing all invalid
+// fields, or nil when every checked field is valid
+func (v *Validator) Err() error {
+	if len(v.details) == 0 {
+		return nil
+	}
+	return domain.ErrBadParameters.WithDetails(v.details...)
+}
+
+// Invalid reports whether field already violates a rule
+func (v *Validator) Invalid(field string) bool {
+	for _, detail := range v.details {
+		if detail.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Check adds detail of violated rule unless ok or field is already invalid
+func (v *Validator) Check(ok bool, field string, rule string, messagef string, args ...interface{}) bool {
+	if v.Invalid(field) {
+		return false
+	}
+	if ok {
+		return true
+	}
+	v.details = append(v.details, domain.ErrorDetail{
+		Field:   field,
+		Code:    rule,
+		Message: fmt.Sprintf(messagef, args...),
+	})
+	return false
+}
+
+// Required checks that value is not blank
+func (v *Validator) Required(field string, value string) bool {
+	return v.Check(len(strings.TrimSpace(value)) > 0, field, RuleRequired, "%s is required", field)
+}
+
+// Length checks that value has min to max characters, inclusive
+func (v *Validator) Length(field string, value string, min int, max int) bool {
+	length := utf8.RuneCountInString(value)
+	return v.Check(length >= min, field, RuleMinLength, "%s must be at least %d characters", field, min) &&
+		v.Check(length <= max, field, RuleMaxLength, "%s must be at most %d characters", field, max)
+}
+
+// Pattern checks that value matches re, described to client by description
+func (v *Validator) Pattern(field string, value string, re *regexp.Regexp, description string) bool {
+	return v.Check(re.MatchString(value), field, RulePattern, "%s must %s", field, description)
+}
+
+// Email checks that value is an email address (see IsEmail)
+func (v *Validator) Email(field string, value string) bool {
+	return v.Check(IsEmail(value), field, RuleEmail, "%s must be a valid email address", field)
+}
@@ -0,0 +1,100 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iqdf/golumn-story-service/domain"
+)
+
+func TestIsEmail(t *testing.T) {
+	valids := []string{
+		"user@example.com",
+		"User.Zero+tag@mail.example.co.id",
+		"o'brien@example.com",
+		"!#$%&'*+-/=?^_`{|}~@example.com",
+		`"Abc@def"@example.com`,
+		`"Fred\ Bloggs"@example.com`,
+		`"Joe.\\Blow"@example.com`,
+		"user@xn--80ak6aa92e.com",
+		"user@123.example.com",
+	}
+	for _, address := range valids {
+		require.True(t, IsEmail(address), "expect valid %q", address)
+	}
+
+	invalids := []string{
+		"",
+		"user",
+		"@example.com",
+		"user@",
+		"user@localhost",
+		".user@example.com",
+		"user.@example.com",
+		"us..er@example.com",
+		"us er@example.com",
+		"user@example..com",
+		"user@-example.com",
+		"user@example-.com",
+		"user@example.123",
+		"user@[10.0.0.1]",
+		`"unterminated@example.com`,
+		`"un"escaped"@example.com`,
+		strings.Repeat("a", MaxEmailLocalLength+1) + "@example.com",
+		"user@" + strings.Repeat("a", 64) + ".com",
+	}
+	for _, address := range invalids {
+		require.False(t, IsEmail(address), "expect invalid %q", address)
+	}
+}
+
+func TestValidateUser(t *testing.T) {
+	user := domain.User{Email: "user@example.com", Username: "UserZero", Name: "Zero"}
+	require.NoError(t, ValidateUser(user))
+
+	user.Name = "Ñø" // characters, not bytes, are counted
+	require.NoError(t, ValidateUser(user))
+}
+
+func TestValidateUserDetails(t *testing.T) {
+	user := domain.User{
+		Email:       "",
+		Username:    strings.Repeat("u", MaxUsernameLength+1),
+		Name:        "N",
+		Description: strings.Repeat("d", MaxDescriptionLength+1),
+	}
+
+	err := ValidateUser(user)
+	appErr, ok := err.(*domain.AppError)
+	require.True(t, ok, "expect *domain.AppError, got %T", err)
+	require.Equal(t, domain.InvalidParamCode, appErr.Code())
+	require.Equal(t, []domain.ErrorDetail{
+		{Field: "email", Code: RuleRequired, Message: "email is required"},
+		{Field: "username", Code: RuleMaxLength, Message: "username must be at most 40 characters"},
+		{Field: "name", Code: RuleMinLength, Message: "name must be at least 2 characters"},
+		{Field: "description", Code: RuleMaxLength, Message: "description must be at most 256 characters"},
+	}, appErr.Details)
+	require.Contains(t, appErr.Message(), "email, username, name, description")
+}
+
+func TestValidateUsername(t *testing.T) {
+	require.NoError(t, ValidateUsername("user.zero_0-1"))
+
+	err := ValidateUsername("user/zero")
+	require.Error(t, err)
+	require.Equal(t, RulePattern, err.(*domain.AppError).Details[0].Code)
+
+	err = ValidateUsername("User")
+	require.Error(t, err)
+	require.Equal(t, RuleMinLength, err.(*domain.AppError).Details[0].Code)
+}
+
+func TestValidateEmail(t *testing.T) {
+	require.NoError(t, ValidateEmail("user@example.com"))
+
+	err := ValidateEmail(strings.Repeat("u", 30) + "@example.com")
+	require.Error(t, err, "email must fit its column")
+	require.Equal(t, RuleMaxLength, err.(*domain.AppError).Details[0].Code)
+}
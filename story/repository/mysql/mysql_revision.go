@@ -0,0 +1,205 @@
+package mysql
+
+import (
+	// import built-in libraries
+	"reflect"
+	"strconv"
+	"time"
+
+	// import third-party libraries
+	"github.com/jinzhu/gorm"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+	repocommon "github.com/iqdf/golumn-story-service/lib/repository"
+	usermysql "github.com/iqdf/golumn-story-service/user/repository/mysql"
+)
+
+// StoryRevisionDB ...
+type StoryRevisionDB struct {
+	ID        uint64 `gorm:"PRIMARY_KEY"`
+	StoryID   uint64 `gorm:"UNIQUE_INDEX:idx_story_revision_number;NOT NULL"`
+	Number    int    `gorm:"UNIQUE_INDEX:idx_story_revision_number;NOT NULL"`
+	Title     string `gorm:"Type:VARCHAR(128);NOT NULL"`
+	Subtitle  string `gorm:"Type:VARCHAR(256)"`
+	Body      string `gorm:"Type:TEXT"`
+	CreatedAt time.Time
+}
+
+// NewStoryRevisionDBWriter ...
+func NewStoryRevisionDBWriter(revision domain.StoryRevision) StoryRevisionDB {
+	return StoryRevisionDB{
+		StoryID:   revision.StoryID,
+		Title:     revision.Title,
+		Subtitle:  revision.Subtitle,
+		Body:      revision.Body,
+		CreatedAt: time.Now(),
+	}
+}
+
+// StoryRevisionColumns return list of column names
+func StoryRevisionColumns() []string {
+	revision := StoryRevisionDB{}
+	val := reflect.Indirect(reflect.ValueOf(revision))
+
+	columns := make([]string, 0, val.NumField())
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		columns = append(columns, usermysql.ToSnakeCase(field.Name))
+	}
+	return columns
+}
+
+// TableName ...
+func (revisionDB *StoryRevisionDB) TableName() string {
+	return "story_revisions"
+}
+
+// StoryRevision ...
+func (revisionDB *StoryRevisionDB) StoryRevision() domain.StoryRevision {
+	return domain.StoryRevision{
+		ID:        revisionDB.ID,
+		StoryID:   revisionDB.StoryID,
+		Number:    revisionDB.Number,
+		Title:     revisionDB.Title,
+		Subtitle:  revisionDB.Subtitle,
+		Body:      revisionDB.Body,
+		CreatedAt: revisionDB.CreatedAt,
+	}
+}
+
+// StoryRevisionMySQLRepository ...
+type StoryRevisionMySQLRepository struct {
+	DB     *gorm.DB
+	Rand   UIntRandomizer
+	ErrCvt DBErrorConverter
+}
+
+// NewStoryRevisionMySQLRepository ...
+func NewStoryRevisionMySQLRepository(db *gorm.DB, rand UIntRandomizer) *StoryRevisionMySQLRepository {
+	return &StoryRevisionMySQLRepository{
+		DB:     db,
+		Rand:   rand,
+		ErrCvt: repocommon.NewMySQLErrCvt(),
+	}
+}
+
+func (revisionRepo *StoryRevisionMySQLRepository) generateID() uint64 {
+	return revisionRepo.Rand.Uint64()
+}
+
+// GetRevision ...
+func (revisionRepo *StoryRevisionMySQLRepository) GetRevision(storyID uint64, number int) (domain.StoryRevision, error) {
+	var (
+		revisionDB = new(StoryRevisionDB)
+		db         = revisionRepo.DB
+	)
+	// SELECT * FROM `story_revisions` WHERE (story_id = ? AND number = ?)
+	// ORDER BY `story_revisions`.`id` LIMIT 1
+	err := db.Where("story_id = ? AND number = ?", storyID, number).First(&revisionDB).Error
+	appErr := revisionRepo.ErrCvt.AppError(err, "revisionrepo: find revision by number fail")
+
+	return revisionDB.StoryRevision(), appErr
+}
+
+// ListRevisions returns paginated revisions of a story, newest first
+func (revisionRepo *StoryRevisionMySQLRepository) ListRevisions(storyID uint64, page int, limit int) ([]domain.StoryRevision, domain.Metadata, error) {
+	var (
+		revisionsDB = make([]StoryRevisionDB, 0)
+		total       int
+		db          = revisionRepo.DB.Model(&StoryRevisionDB{}).Where("story_id = ?", storyID)
+	)
+	offset, limit := pagination(page, limit)
+
+	// SELECT count(*) FROM `story_revisions` WHERE (story_id = ?)
+	if err := db.Count(&total).Error; err != nil {
+		appErr := revisionRepo.ErrCvt.AppError(err, "revisionrepo: count story revisions fail")
+		return nil, domain.Metadata{}, appErr
+	}
+
+	// SELECT * FROM `story_revisions` WHERE (story_id = ?)
+	// ORDER BY number DESC LIMIT (limit) OFFSET (offset)
+	err := db.Order("number DESC").Offset(offset).Limit(limit).Find(&revisionsDB).Error
+	if err != nil {
+		appErr := revisionRepo.ErrCvt.AppError(err, "revisionrepo: list story revisions fail")
+		return nil, domain.Metadata{}, appErr
+	}
+
+	revisions := make([]domain.StoryRevision, 0, len(revisionsDB))
+	for _, revisionDB := range revisionsDB {
+		revisions = append(revisions, revisionDB.StoryRevision())
+	}
+
+	meta := domain.Metadata{Total: total, Page: offset/limit + 1, Limit: limit}
+	if offset+len(revisions) < total {
+		meta.NextCursor = strconv.Itoa(meta.Page + 1)
+	}
+	return revisions, meta, nil
+}
+
+// InsertRevision inserts revision numbered after the latest
+// revision of the story. Concurrent inserts of the same story
+// are serialized by locking its story row, as PostgreSQL does
+// not allow locking the aggregate of its latest revision.
+func (revisionRepo *StoryRevisionMySQLRepository) InsertRevision(revision domain.StoryRevision) (domain.StoryRevision, error) {
+	var (
+		revisionDB = NewStoryRevisionDBWriter(revision)
+		db         = revisionRepo.DB
+	)
+	revisionDB.ID = revisionRepo.generateID()
+
+	err := repocommon.Transaction(db, func(tx *gorm.DB) error {
+		var (
+			story  struct{ ID uint64 }
+			latest struct{ Number int }
+		)
+
+		// SELECT id FROM `stories` WHERE (id = ?) FOR UPDATE
+		err := tx.Model(&StoryDB{}).Set("gorm:query_option", "FOR UPDATE").
+			Select("id").Where("id = ?", revisionDB.StoryID).Scan(&story).Error
+		if err != nil {
+			return err
+		}
+
+		// SELECT COALESCE(MAX(number), 0) AS number FROM `story_revisions`
+		// WHERE (story_id = ?)
+		err = tx.Model(&StoryRevisionDB{}).
+			Select("COALESCE(MAX(number), 0) AS number").
+			Where("story_id = ?", revisionDB.StoryID).Scan(&latest).Error
+		if err != nil {
+			return err
+		}
+		revisionDB.Number = latest.Number + 1
+
+		// INSERT INTO `story_revisions` (...) VALUES (...)
+		return tx.Create(&revisionDB).Error
+	})
+	if err != nil {
+		appErr := revisionRepo.ErrCvt.AppError(err, "revisionrepo: insert revision fail")
+		return domain.StoryRevision{}, appErr
+	}
+	return revisionDB.StoryRevision(), nil
+}
+
+// DeleteByStory deletes every revision of story, it must be
+// called before deleting the story itself. Deleting no revision
+// is not an error.
+func (revisionRepo *StoryRevisionMySQLRepository) DeleteByStory(storyID uint64) error {
+	db := revisionRepo.DB
+
+	// DELETE FROM `story_revisions` WHERE (story_id = ?)
+	err := db.Where("story_id = ?", storyID).Delete(&StoryRevisionDB{}).Error
+	return revisionRepo.ErrCvt.AppError(err, "revisionrepo: delete revisions by story fail")
+}
+
+// DeleteByAuthor deletes every revision of stories of author,
+// it must be called before deleting the stories themselves
+func (revisionRepo *StoryRevisionMySQLRepository) DeleteByAuthor(authorID uint64) error {
+	db := revisionRepo.DB
+
+	// DELETE FROM `story_revisions` WHERE
+	// (story_id IN (SELECT id FROM `stories` WHERE (author_id = ?)))
+	stories := db.Model(&StoryDB{}).Select("id").Where("author_id = ?", authorID).SubQuery()
+	err := db.Where("story_id IN ?", stories).Delete(&StoryRevisionDB{}).Error
+	return revisionRepo.ErrCvt.AppError(err, "revisionrepo: delete revisions by author fail")
+}
@@ -0,0 +1,162 @@
+package mysql
+
+import (
+	// import built-in libraries
+	"database/sql/driver"
+	"errors"
+	"regexp"
+
+	// import third-party libraries
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-test/deep"
+	"github.com/iqdf/golumn-story-service/domain"
+)
+
+// Warning! Columns order is important!
+// MUST Modify this func if StoryRevisionDB model changes!
+// See StoryRevisionColumns() for list of columns.
+func revisionToRows(revision domain.StoryRevision) []driver.Value {
+	return []driver.Value{
+		revision.ID, revision.StoryID, revision.Number,
+		revision.Title, revision.Subtitle, revision.Body,
+		revision.CreatedAt,
+	}
+}
+
+var mockRevision = domain.StoryRevision{
+	ID:        72,
+	StoryID:   71,
+	Number:    2,
+	Title:     "Hello, Golumn!",
+	Subtitle:  "First story",
+	Body:      "Once upon a time",
+	CreatedAt: mockTime,
+}
+
+func (tsuite *TestSuite) revisionRepository() *StoryRevisionMySQLRepository {
+	return NewStoryRevisionMySQLRepository(tsuite.DB, NewIDMocker())
+}
+
+func (tsuite *TestSuite) TestShouldGetRevision() {
+	rows := sqlmock.NewRows(StoryRevisionColumns()).
+		AddRow(revisionToRows(mockRevision)...)
+
+	queryStr := regexp.QuoteMeta("SELECT * FROM `story_revisions` " +
+		"WHERE (story_id = ? AND number = ?) ORDER BY `story_revisions`.`id` ASC LIMIT 1")
+
+	// register expected query and mocked rows
+	tsuite.Mock.ExpectQuery(queryStr).
+		WithArgs(mockRevision.StoryID, mockRevision.Number).
+		WillReturnRows(rows)
+
+	// run gorm query: get revision by number
+	revision, err := tsuite.revisionRepository().GetRevision(mockRevision.StoryID, mockRevision.Number)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Nil(deep.Equal(revision, mockRevision))
+}
+
+func (tsuite *TestSuite) TestShouldListRevisions() {
+	countRows := sqlmock.NewRows([]string{"count(*)"}).AddRow(2)
+	rows := sqlmock.NewRows(StoryRevisionColumns()).
+		AddRow(revisionToRows(mockRevision)...)
+
+	countStr := regexp.QuoteMeta("SELECT count(*) FROM `story_revisions` WHERE (story_id = ?)")
+	queryStr := regexp.QuoteMeta("SELECT * FROM `story_revisions` WHERE (story_id = ?) " +
+		"ORDER BY number DESC LIMIT 1 OFFSET 0")
+
+	// register expected count then select queries
+	tsuite.Mock.ExpectQuery(countStr).
+		WithArgs(mockRevision.StoryID).
+		WillReturnRows(countRows)
+	tsuite.Mock.ExpectQuery(queryStr).
+		WithArgs(mockRevision.StoryID).
+		WillReturnRows(rows)
+
+	// run gorm query: latest revision
+	revisions, meta, err := tsuite.revisionRepository().ListRevisions(mockRevision.StoryID, 1, 1)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(revisions, 1)
+	tsuite.Require().Equal(domain.Metadata{Total: 2, Page: 1, Limit: 1, NextCursor: "2"}, meta)
+}
+
+func (tsuite *TestSuite) TestShouldInsertNextRevision() {
+	storyRows := sqlmock.NewRows([]string{"id"}).AddRow(mockRevision.StoryID)
+	latestRows := sqlmock.NewRows([]string{"number"}).AddRow(1)
+
+	lockStr := regexp.QuoteMeta("SELECT id FROM `stories` WHERE (id = ?) FOR UPDATE")
+	latestStr := regexp.QuoteMeta("SELECT COALESCE(MAX(number), 0) AS number FROM `story_revisions` " +
+		"WHERE (story_id = ?)")
+	insertStr := regexp.QuoteMeta("INSERT INTO `story_revisions` " +
+		"(`id`,`story_id`,`number`,`title`,`subtitle`,`body`,`created_at`) VALUES (?,?,?,?,?,?,?)")
+
+	// register expected tx operations, story is locked and
+	// revision number incremented within one transaction
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectQuery(lockStr).
+		WithArgs(mockRevision.StoryID).
+		WillReturnRows(storyRows)
+	tsuite.Mock.ExpectQuery(latestStr + "$").
+		WithArgs(mockRevision.StoryID).
+		WillReturnRows(latestRows)
+	tsuite.Mock.ExpectExec(insertStr).
+		WithArgs(71, mockRevision.StoryID, 2, mockRevision.Title,
+			mockRevision.Subtitle, mockRevision.Body, AnyTimeArg{}).
+		WillReturnResult(sqlmock.NewResult(71, 1))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: insert next revision
+	revision, err := tsuite.revisionRepository().InsertRevision(domain.StoryRevision{
+		StoryID:  mockRevision.StoryID,
+		Title:    mockRevision.Title,
+		Subtitle: mockRevision.Subtitle,
+		Body:     mockRevision.Body,
+	})
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(2, revision.Number)
+}
+
+func (tsuite *TestSuite) TestShouldNotInsertRevisionOfUnknownStory() {
+	lockStr := regexp.QuoteMeta("SELECT id FROM `stories` WHERE (id = ?) FOR UPDATE")
+
+	// register expected tx operations, nothing is inserted
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectQuery(lockStr).
+		WithArgs(uint64(404)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	tsuite.Mock.ExpectRollback()
+
+	// run gorm tx: insert revision of unknown story
+	_, err := tsuite.revisionRepository().InsertRevision(domain.StoryRevision{StoryID: 404, Title: mockRevision.Title})
+	tsuite.Require().True(errors.Is(err, domain.ErrUnknownResource), "got %v", err)
+}
+
+func (tsuite *TestSuite) TestShouldDeleteRevisionsByStory() {
+	execStr := regexp.QuoteMeta("DELETE FROM `story_revisions` WHERE (story_id = ?)")
+
+	// register expected tx operation
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(execStr).
+		WithArgs(mockStory.ID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: delete revisions of mock story
+	err := tsuite.revisionRepository().DeleteByStory(mockStory.ID)
+	tsuite.Require().NoError(err)
+}
+
+func (tsuite *TestSuite) TestShouldDeleteRevisionsByAuthor() {
+	execStr := regexp.QuoteMeta("DELETE FROM `story_revisions` " +
+		"WHERE (story_id IN (SELECT id FROM `stories` WHERE (author_id = ?)))")
+
+	// register expected tx operation
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(execStr).
+		WithArgs(mockStory.AuthorID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: delete revisions of stories of mock author
+	err := tsuite.revisionRepository().DeleteByAuthor(mockStory.AuthorID)
+	tsuite.Require().NoError(err)
+}
@@ -0,0 +1,346 @@
+package mysql
+
+import (
+	// import built-in libraries
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	// import third-party libraries
+	"github.com/jinzhu/gorm"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+	repocommon "github.com/iqdf/golumn-story-service/lib/repository"
+	usermysql "github.com/iqdf/golumn-story-service/user/repository/mysql"
+)
+
+// DefaultLimit is the default and maximum
+// number of rows returned by paginated query
+const DefaultLimit uint = 20
+
+// StoryDB ...
+type StoryDB struct {
+	ID          uint64 `gorm:"PRIMARY_KEY"`
+	AuthorID    uint64 `gorm:"INDEX;NOT NULL"`
+	Title       string `gorm:"Type:VARCHAR(128);NOT NULL"`
+	Subtitle    string `gorm:"Type:VARCHAR(256)"`
+	Body        string `gorm:"Type:TEXT"`
+	Slug        string `gorm:"Type:VARCHAR(160);UNIQUE_INDEX;NOT NULL"`
+	Status      string `gorm:"Type:VARCHAR(16);INDEX;NOT NULL;DEFAULT:'draft'"`
+	ReadingTime int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	PublishedAt *time.Time
+	// PublishedRevision is StoryRevisionDB.Number pinned as live
+	PublishedRevision int `gorm:"NOT NULL"`
+}
+
+// NewStoryDBWriter ...
+func NewStoryDBWriter(story domain.Story) StoryDB {
+	status := story.Status
+	if len(status) == 0 {
+		status = domain.StoryStatusDraft
+	}
+
+	return StoryDB{
+		AuthorID:    story.AuthorID,
+		Title:       story.Title,
+		Subtitle:    story.Subtitle,
+		Body:        story.Body,
+		Slug:        story.Slug,
+		Status:      string(status),
+		ReadingTime: story.ReadingTime,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		PublishedAt: story.PublishedAt,
+
+		PublishedRevision: story.PublishedRevision,
+	}
+}
+
+// NewStoryDBUpdater ...
+func NewStoryDBUpdater(storyID uint64, story domain.Story) StoryDB {
+	return StoryDB{
+		Title:       story.Title,
+		Subtitle:    story.Subtitle,
+		Body:        story.Body,
+		ReadingTime: story.ReadingTime,
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// StoryColumns return list of column names
+func StoryColumns() []string {
+	story := StoryDB{}
+	val := reflect.Indirect(reflect.ValueOf(story))
+
+	columns := make([]string, 0, val.NumField())
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		columns = append(columns, usermysql.ToSnakeCase(field.Name))
+	}
+	return columns
+}
+
+// TableName ...
+func (storyDB *StoryDB) TableName() string {
+	return "stories"
+}
+
+// Story ...
+func (storyDB *StoryDB) Story() domain.Story {
+	return domain.Story{
+		ID:          storyDB.ID,
+		AuthorID:    storyDB.AuthorID,
+		Title:       storyDB.Title,
+		Subtitle:    storyDB.Subtitle,
+		Body:        storyDB.Body,
+		Slug:        storyDB.Slug,
+		Status:      domain.StoryStatus(storyDB.Status),
+		ReadingTime: storyDB.ReadingTime,
+		CreatedAt:   storyDB.CreatedAt,
+		UpdatedAt:   storyDB.UpdatedAt,
+		PublishedAt: storyDB.PublishedAt,
+
+		PublishedRevision: storyDB.PublishedRevision,
+	}
+}
+
+// UIntRandomizer ...
+type UIntRandomizer interface {
+	Uint32() uint32
+	Uint64() uint64
+}
+
+// DBErrorConverter ...
+type DBErrorConverter interface {
+	AppError(error, string) error
+}
+
+// StoryMySQLRepository ...
+type StoryMySQLRepository struct {
+	DB     *gorm.DB
+	Rand   UIntRandomizer
+	ErrCvt DBErrorConverter
+}
+
+// NewStoryMySQLRepository ...
+func NewStoryMySQLRepository(db *gorm.DB, rand UIntRandomizer) *StoryMySQLRepository {
+	return &StoryMySQLRepository{
+		DB:     db,
+		Rand:   rand,
+		ErrCvt: repocommon.NewMySQLErrCvt(),
+	}
+}
+
+func (storyRepo *StoryMySQLRepository) generateID() uint64 {
+	return storyRepo.Rand.Uint64()
+}
+
+// GetByID ...
+func (storyRepo *StoryMySQLRepository) GetByID(storyID uint64) (domain.Story, error) {
+	var (
+		storyDB = new(StoryDB)
+		db      = storyRepo.DB
+	)
+	// SELECT * FROM `stories` WHERE (id = ?) ORDER BY `stories`.`id` LIMIT 1
+	err := db.Where("id = ?", storyID).First(&storyDB).Error
+	appErr := storyRepo.ErrCvt.AppError(err, "storyrepo: find story by id fail")
+
+	return storyDB.Story(), appErr
+}
+
+// GetBySlug ...
+func (storyRepo *StoryMySQLRepository) GetBySlug(slug string) (domain.Story, error) {
+	var (
+		storyDB = new(StoryDB)
+		db      = storyRepo.DB
+	)
+	// SELECT * FROM `stories` WHERE (slug = ?) ORDER BY `stories`.`id` LIMIT 1
+	err := db.Where("slug = ?", slug).First(&storyDB).Error
+	appErr := storyRepo.ErrCvt.AppError(err, "storyrepo: find story by slug fail")
+
+	return storyDB.Story(), appErr
+}
+
+// FetchByAuthor returns paginated stories of an author, newest first
+func (storyRepo *StoryMySQLRepository) FetchByAuthor(authorID uint64, page int, limit int) ([]domain.Story, domain.Metadata, error) {
+	// SELECT * FROM `stories` WHERE (author_id = ?)
+	db := storyRepo.DB.Model(&StoryDB{}).Where("author_id = ?", authorID)
+	return storyRepo.fetchStories(db, page, limit)
+}
+
+// FetchPublishedByAuthor returns paginated published
+// stories of an author, newest first
+func (storyRepo *StoryMySQLRepository) FetchPublishedByAuthor(authorID uint64, page int, limit int) ([]domain.Story, domain.Metadata, error) {
+	// SELECT * FROM `stories` WHERE (author_id = ? AND status = ?)
+	db := storyRepo.DB.Model(&StoryDB{}).
+		Where("author_id = ? AND status = ?", authorID, domain.StoryStatusPublished)
+	return storyRepo.fetchStories(db, page, limit)
+}
+
+// fetchStories returns paginated stories matched by db, newest first
+func (storyRepo *StoryMySQLRepository) fetchStories(db *gorm.DB, page int, limit int) ([]domain.Story, domain.Metadata, error) {
+	var (
+		storiesDB = make([]StoryDB, 0)
+		total     int
+	)
+	offset, limit := pagination(page, limit)
+
+	// SELECT count(*) FROM `stories` WHERE (conditions)
+	if err := db.Count(&total).Error; err != nil {
+		appErr := storyRepo.ErrCvt.AppError(err, "storyrepo: count author stories fail")
+		return nil, domain.Metadata{}, appErr
+	}
+
+	// SELECT * FROM `stories` WHERE (conditions)
+	// ORDER BY created_at DESC LIMIT (limit) OFFSET (offset)
+	err := db.Order("created_at DESC").Offset(offset).Limit(limit).Find(&storiesDB).Error
+	if err != nil {
+		appErr := storyRepo.ErrCvt.AppError(err, "storyrepo: fetch author stories fail")
+		return nil, domain.Metadata{}, appErr
+	}
+
+	stories := make([]domain.Story, 0, len(storiesDB))
+	for _, storyDB := range storiesDB {
+		stories = append(stories, storyDB.Story())
+	}
+
+	meta := domain.Metadata{Total: total, Page: offset/limit + 1, Limit: limit}
+	if offset+len(stories) < total {
+		meta.NextCursor = strconv.Itoa(meta.Page + 1)
+	}
+	return stories, meta, nil
+}
+
+// InsertOne ...
+func (storyRepo *StoryMySQLRepository) InsertOne(story domain.Story) (domain.Story, error) {
+	var (
+		storyDB = NewStoryDBWriter(story)
+		db      = storyRepo.DB
+	)
+	storyDB.ID = storyRepo.generateID()
+	storyDB.Slug = uniqueSlug(storyDB.Slug, storyDB.ID)
+
+	// INSERT INTO `stories` (...) VALUES (...)
+	db = db.Create(&storyDB)
+	if err := db.Error; err != nil || db.RowsAffected == 0 {
+		appErr := storyRepo.ErrCvt.AppError(err, "storyrepo: insert one story fail")
+		return domain.Story{}, appErr
+	}
+	return storyDB.Story(), nil
+}
+
+// UpdateOne ...
+func (storyRepo *StoryMySQLRepository) UpdateOne(storyID uint64, story domain.Story) (domain.Story, error) {
+	var (
+		storyDB = NewStoryDBUpdater(storyID, story)
+		db      = storyRepo.DB
+	)
+
+	// UPDATE `stories` SET body = (body), reading_time = (readingTime),
+	// subtitle = (subtitle), title = (title), updated_at = (now)
+	// WHERE id = (storyID)
+	// map writes zero values too, e.g. body of restored empty revision
+	db = db.Model(&StoryDB{ID: storyID}).Updates(map[string]interface{}{
+		"title":        storyDB.Title,
+		"subtitle":     storyDB.Subtitle,
+		"body":         storyDB.Body,
+		"reading_time": storyDB.ReadingTime,
+		"updated_at":   storyDB.UpdatedAt,
+	})
+	if err := rowsAffectedError(db); err != nil {
+		appErr := storyRepo.ErrCvt.AppError(err, "storyrepo: update one story fail")
+		return domain.Story{}, appErr
+	}
+
+	return storyDB.Story(), nil
+}
+
+// UpdateStatus ...
+func (storyRepo *StoryMySQLRepository) UpdateStatus(storyID uint64, story domain.Story) (domain.Story, error) {
+	var (
+		storyDB = StoryDB{
+			ID:                storyID,
+			Status:            string(story.Status),
+			PublishedAt:       story.PublishedAt,
+			PublishedRevision: story.PublishedRevision,
+			UpdatedAt:         time.Now(),
+		}
+		db = storyRepo.DB
+	)
+
+	// UPDATE `stories` SET published_at = (publishedAt), published_revision = (revision),
+	// status = (status), updated_at = (now) WHERE id = (storyID)
+	db = db.Model(&StoryDB{ID: storyID}).Updates(map[string]interface{}{
+		"status":             storyDB.Status,
+		"published_at":       storyDB.PublishedAt,
+		"published_revision": storyDB.PublishedRevision,
+		"updated_at":         storyDB.UpdatedAt,
+	})
+	if err := rowsAffectedError(db); err != nil {
+		appErr := storyRepo.ErrCvt.AppError(err, "storyrepo: update story status fail")
+		return domain.Story{}, appErr
+	}
+
+	return storyDB.Story(), nil
+}
+
+// DeleteOne ...
+func (storyRepo *StoryMySQLRepository) DeleteOne(storyID uint64) error {
+	var (
+		storyDB = &StoryDB{ID: storyID}
+		db      = storyRepo.DB
+	)
+
+	// DELETE FROM `stories` WHERE id = ?
+	db = db.Delete(&storyDB)
+	if err := rowsAffectedError(db); err != nil {
+		appErr := storyRepo.ErrCvt.AppError(err, "storyrepo: delete one story fail")
+		return appErr
+	}
+	return nil
+}
+
+// DeleteByAuthor deletes every story of author,
+// deleting no story is not an error
+func (storyRepo *StoryMySQLRepository) DeleteByAuthor(authorID uint64) error {
+	db := storyRepo.DB
+
+	// DELETE FROM `stories` WHERE (author_id = ?)
+	err := db.Where("author_id = ?", authorID).Delete(&StoryDB{}).Error
+	return storyRepo.ErrCvt.AppError(err, "storyrepo: delete stories by author fail")
+}
+
+// rowsAffectedError returns db error, or gorm.ErrRecordNotFound
+// when the statement does not affect any row
+func rowsAffectedError(db *gorm.DB) error {
+	if db.Error == nil && db.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return db.Error
+}
+
+// uniqueSlug suffixes slug with base36 story id, as
+// stories with the same title must have distinct slug
+func uniqueSlug(slug string, storyID uint64) string {
+	suffix := strconv.FormatUint(storyID, 36)
+	if slug = strings.Trim(slug, "-"); len(slug) == 0 {
+		return suffix
+	}
+	return slug + "-" + suffix
+}
+
+// pagination converts 1-indexed page and limit to row offset and limit.
+// Non-positive page defaults to 1, limit is bounded to DefaultLimit.
+func pagination(page int, limit int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > int(DefaultLimit) {
+		limit = int(DefaultLimit)
+	}
+	return (page - 1) * limit, limit
+}
@@ -0,0 +1,291 @@
+package mysql
+
+import (
+	// import built-in libraries
+	"database/sql/driver"
+	"log"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	// import third-party libraries
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-test/deep"
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// AnyTimeArg matches sql Args of type time.Time
+// without caring the time value
+type AnyTimeArg struct{}
+
+func (a AnyTimeArg) Match(v driver.Value) bool {
+	_, ok := v.(time.Time)
+	return ok
+}
+
+// StoryIDMocker mocks storyID generation in StoryRepo
+// such that it always generates the same id
+type StoryIDMocker struct{}
+
+func NewIDMocker() *StoryIDMocker { return &StoryIDMocker{} }
+
+func (mockID *StoryIDMocker) Uint32() uint32 { return 71 }
+
+func (mockID *StoryIDMocker) Uint64() uint64 { return 71 }
+
+type TestSuite struct {
+	suite.Suite
+	DB         *gorm.DB
+	Mock       sqlmock.Sqlmock
+	Repository *StoryMySQLRepository
+}
+
+func (tsuite *TestSuite) SetupSuite() {
+	db, mock, err := sqlmock.New()
+	require.NoError(tsuite.T(), err)
+
+	tsuite.DB, err = gorm.Open("mysql", db)
+	require.NoError(tsuite.T(), err)
+
+	tsuite.DB.SetLogger(log.New(os.Stdout, "\r\n", 0))
+	tsuite.DB.LogMode(true)
+
+	tsuite.Mock = mock
+
+	storyIDMocker := NewIDMocker()
+	tsuite.Repository = NewStoryMySQLRepository(tsuite.DB, storyIDMocker)
+}
+
+func (tsuite *TestSuite) AfterTest(_, _ string) {
+	require.NoError(tsuite.T(), tsuite.Mock.ExpectationsWereMet())
+}
+
+func TestInit(t *testing.T) {
+	suite.Run(t, new(TestSuite))
+}
+
+var mockTime = time.Date(2020, time.April, 16, 8, 0, 0, 0, time.UTC)
+
+// Warning! Columns order is important!
+// MUST Modify this func if StoryDB model changes!
+// See StoryColumns() for list of columns.
+func storyToRows(story domain.Story) []driver.Value {
+	return []driver.Value{
+		story.ID, story.AuthorID, story.Title,
+		story.Subtitle, story.Body, story.Slug,
+		string(story.Status), story.ReadingTime,
+		story.CreatedAt, story.UpdatedAt, story.PublishedAt,
+		story.PublishedRevision,
+	}
+}
+
+var mockStory = domain.Story{
+	ID:          71,
+	AuthorID:    1,
+	Title:       "Hello, Golumn!",
+	Subtitle:    "First story",
+	Body:        "Once upon a time",
+	Slug:        "hello-golumn-1z",
+	Status:      domain.StoryStatusDraft,
+	ReadingTime: 1,
+	CreatedAt:   mockTime,
+	UpdatedAt:   mockTime,
+}
+
+func (tsuite *TestSuite) TestShouldGetByID() {
+	rows := sqlmock.NewRows(StoryColumns()).
+		AddRow(storyToRows(mockStory)...)
+
+	queryStr := regexp.QuoteMeta("SELECT * FROM `stories` WHERE (id = ?) ORDER BY `stories`.`id` ASC LIMIT 1")
+
+	// register sequence of expected operations
+	// and defined returned rows to be mocked
+	tsuite.Mock.ExpectQuery(queryStr).
+		WithArgs(mockStory.ID).
+		WillReturnRows(rows)
+
+	// run gorm tx - get story by id
+	getStory, err := tsuite.Repository.GetByID(mockStory.ID)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Nil(deep.Equal(getStory, mockStory))
+}
+
+func (tsuite *TestSuite) TestShouldNotGetUnknownSlug() {
+	queryStr := regexp.QuoteMeta("SELECT * FROM `stories` WHERE (slug = ?) ORDER BY `stories`.`id` ASC LIMIT 1")
+
+	// register expected query returning no rows
+	tsuite.Mock.ExpectQuery(queryStr).
+		WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows(StoryColumns()))
+
+	// run gorm tx - get story by slug
+	_, err := tsuite.Repository.GetBySlug("unknown")
+	tsuite.Require().Error(err)
+	tsuite.Require().Equal(domain.UnknownResourceCode, err.(*domain.AppError).Code())
+}
+
+func (tsuite *TestSuite) TestShouldFetchByAuthor() {
+	countRows := sqlmock.NewRows([]string{"count(*)"}).AddRow(1)
+	rows := sqlmock.NewRows(StoryColumns()).
+		AddRow(storyToRows(mockStory)...)
+
+	countStr := regexp.QuoteMeta("SELECT count(*) FROM `stories` WHERE (author_id = ?)")
+	queryStr := regexp.QuoteMeta("SELECT * FROM `stories` WHERE (author_id = ?) " +
+		"ORDER BY created_at DESC LIMIT 20 OFFSET 0")
+
+	// register expected count then select queries
+	tsuite.Mock.ExpectQuery(countStr).
+		WithArgs(mockStory.AuthorID).
+		WillReturnRows(countRows)
+	tsuite.Mock.ExpectQuery(queryStr).
+		WithArgs(mockStory.AuthorID).
+		WillReturnRows(rows)
+
+	// run gorm query: first page of author stories
+	stories, meta, err := tsuite.Repository.FetchByAuthor(mockStory.AuthorID, 1, 0)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(stories, 1)
+	tsuite.Require().Equal(domain.Metadata{Total: 1, Page: 1, Limit: 20}, meta)
+}
+
+func (tsuite *TestSuite) TestShouldFetchPublishedByAuthor() {
+	countRows := sqlmock.NewRows([]string{"count(*)"}).AddRow(0)
+	rows := sqlmock.NewRows(StoryColumns())
+
+	countStr := regexp.QuoteMeta("SELECT count(*) FROM `stories` WHERE (author_id = ? AND status = ?)")
+	queryStr := regexp.QuoteMeta("SELECT * FROM `stories` WHERE (author_id = ? AND status = ?) " +
+		"ORDER BY created_at DESC LIMIT 20 OFFSET 0")
+
+	// register expected count then select queries,
+	// draft mockStory is filtered out
+	tsuite.Mock.ExpectQuery(countStr).
+		WithArgs(mockStory.AuthorID, "published").
+		WillReturnRows(countRows)
+	tsuite.Mock.ExpectQuery(queryStr).
+		WithArgs(mockStory.AuthorID, "published").
+		WillReturnRows(rows)
+
+	// run gorm query: first page of author published stories
+	stories, meta, err := tsuite.Repository.FetchPublishedByAuthor(mockStory.AuthorID, 1, 0)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(stories, 0)
+	tsuite.Require().Equal(domain.Metadata{Total: 0, Page: 1, Limit: 20}, meta)
+}
+
+func (tsuite *TestSuite) TestShouldInsertOne() {
+	insertResult := sqlmock.NewResult(71, 1)
+	execStr := regexp.QuoteMeta(
+		"INSERT INTO `stories` " +
+			"(`id`,`author_id`,`title`,`subtitle`,`body`,`slug`,`status`," +
+			"`reading_time`,`created_at`,`updated_at`,`published_at`,`published_revision`) " +
+			"VALUES (?,?,?,?,?,?,?,?,?,?,?,?)")
+
+	// register expected tx operations, slug
+	// is suffixed with base36 of story id
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(execStr).
+		WithArgs(mockStory.ID, mockStory.AuthorID, mockStory.Title,
+			mockStory.Subtitle, mockStory.Body, "hello-golumn-1z",
+			"draft", mockStory.ReadingTime, AnyTimeArg{}, AnyTimeArg{}, nil, 0).
+		WillReturnResult(insertResult)
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: insert mock story
+	story := mockStory
+	story.Slug = "hello-golumn"
+	story.Status = ""
+	insertStory, err := tsuite.Repository.InsertOne(story)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal("hello-golumn-1z", insertStory.Slug)
+}
+
+func (tsuite *TestSuite) TestShouldUpdateEmptyContent() {
+	execStr := regexp.QuoteMeta("UPDATE `stories` SET " +
+		"`body` = ?, `reading_time` = ?, `subtitle` = ?, `title` = ?, `updated_at` = ? " +
+		"WHERE `stories`.`id` = ?")
+
+	// register expected tx operation, empty subtitle and body
+	// of restored revision must overwrite the stored ones
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(execStr).
+		WithArgs("", 0, "", "Restored", AnyTimeArg{}, mockStory.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: update mock story to revision of empty body
+	story, err := tsuite.Repository.UpdateOne(mockStory.ID, domain.Story{Title: "Restored"})
+	tsuite.Require().NoError(err)
+	tsuite.Require().Empty(story.Body)
+}
+
+func (tsuite *TestSuite) TestShouldNotUpdateUnknownStory() {
+	execStr := regexp.QuoteMeta("UPDATE `stories` SET " +
+		"`body` = ?, `reading_time` = ?, `subtitle` = ?, `title` = ?, `updated_at` = ? " +
+		"WHERE `stories`.`id` = ?")
+
+	// register expected tx operation affecting no row
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(execStr).
+		WithArgs("", 0, "", "Renamed", AnyTimeArg{}, 404).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: update unknown story
+	_, err := tsuite.Repository.UpdateOne(404, domain.Story{Title: "Renamed"})
+	tsuite.Require().Error(err)
+	tsuite.Require().Equal(domain.UnknownResourceCode, err.(*domain.AppError).Code())
+}
+
+func (tsuite *TestSuite) TestShouldDeleteOne() {
+	execStr := regexp.QuoteMeta("DELETE FROM `stories` WHERE `stories`.`id` = ?")
+
+	// register expected tx operation
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(execStr).
+		WithArgs(mockStory.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: delete mock story
+	err := tsuite.Repository.DeleteOne(mockStory.ID)
+	tsuite.Require().NoError(err)
+}
+
+func (tsuite *TestSuite) TestShouldDeleteByAuthor() {
+	execStr := regexp.QuoteMeta("DELETE FROM `stories` WHERE (author_id = ?)")
+
+	// register expected tx operation, author
+	// without any story is not an error
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(execStr).
+		WithArgs(mockStory.AuthorID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: delete stories of mock author
+	err := tsuite.Repository.DeleteByAuthor(mockStory.AuthorID)
+	tsuite.Require().NoError(err)
+}
+
+func (tsuite *TestSuite) TestShouldUpdateStatusToDraft() {
+	execStr := regexp.QuoteMeta("UPDATE `stories` SET " +
+		"`published_at` = ?, `published_revision` = ?, `status` = ?, `updated_at` = ? " +
+		"WHERE `stories`.`id` = ?")
+
+	// register expected tx operation, zero values
+	// of unpublished story must be written too
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(execStr).
+		WithArgs(nil, 0, "draft", AnyTimeArg{}, mockStory.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: unpublish mock story
+	story, err := tsuite.Repository.UpdateStatus(mockStory.ID, domain.Story{Status: domain.StoryStatusDraft})
+	tsuite.Require().NoError(err)
+	tsuite.Require().False(story.IsPublished())
+}
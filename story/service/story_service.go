@@ -0,0 +1,401 @@
+package service
+
+import (
+	// import built-in libraries
+	"context"
+	"strings"
+	"time"
+	"unicode"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/lib/diff"
+)
+
+// WordsPerMinute is average reading speed used
+// to estimate reading time of a story
+const WordsPerMinute = 200
+
+// StoryService implements domain.StoryService on top of story-data
+// persistence layer (domain.StoryRepository) and its revision history
+// (domain.StoryRevisionRepository). Story row holds the working draft,
+// while published story shows content of its pinned revision.
+type StoryService struct {
+	storyRepo    domain.StoryRepository
+	revisionRepo domain.StoryRevisionRepository
+	uow          domain.UnitOfWork
+}
+
+// NewStoryService creates new StoryService
+func NewStoryService(storyRepo domain.StoryRepository, revisionRepo domain.StoryRevisionRepository) *StoryService {
+	return &StoryService{
+		storyRepo:    storyRepo,
+		revisionRepo: revisionRepo,
+	}
+}
+
+// NewStoryServiceWithUnitOfWork creates new StoryService whose use-cases
+// writing both story and its revisions run within single transaction of uow
+func NewStoryServiceWithUnitOfWork(storyRepo domain.StoryRepository, revisionRepo domain.StoryRevisionRepository,
+	uow domain.UnitOfWork) *StoryService {
+	return &StoryService{
+		storyRepo:    storyRepo,
+		revisionRepo: revisionRepo,
+		uow:          uow,
+	}
+}
+
+// transaction runs fn with service bound to single transaction
+// of unit of work, or with service itself if there is none
+func (service *StoryService) transaction(fn func(txService *StoryService) error) error {
+	if service.uow == nil {
+		return fn(service)
+	}
+	return service.uow.Do(context.Background(), func(repos domain.Repositories) error {
+		return fn(&StoryService{storyRepo: repos.Stories, revisionRepo: repos.Revisions})
+	})
+}
+
+// GetStory returns story with given id. Author gets its draft content,
+// other callers get live content of published story only.
+func (service *StoryService) GetStory(callerID uint64, storyID uint64) (domain.Story, error) {
+	story, err := service.storyRepo.GetByID(storyID)
+	if err != nil {
+		return domain.Story{}, err
+	}
+	if story.AuthorID == callerID {
+		return story, nil
+	}
+	return service.liveStory(story)
+}
+
+// GetStoryBySlug returns live content of published story with given slug
+func (service *StoryService) GetStoryBySlug(slug string) (domain.Story, error) {
+	slug = strings.TrimSpace(slug)
+	if len(slug) == 0 {
+		return domain.Story{}, domain.ErrBadParameters.WithFieldMessagef("slug", domain.DetailRequired, "slug is required")
+	}
+
+	story, err := service.storyRepo.GetBySlug(slug)
+	if err != nil {
+		return domain.Story{}, err
+	}
+	return service.liveStory(story)
+}
+
+// ListAuthorStories returns paginated stories written by author. Author
+// gets all its stories, other callers get published stories only.
+func (service *StoryService) ListAuthorStories(callerID uint64, authorID uint64, page int, limit int) ([]domain.Story, domain.Metadata, error) {
+	if callerID == authorID {
+		return service.storyRepo.FetchByAuthor(authorID, page, limit)
+	}
+
+	stories, meta, err := service.storyRepo.FetchPublishedByAuthor(authorID, page, limit)
+	if err != nil {
+		return nil, domain.Metadata{}, err
+	}
+	for i := range stories {
+		if stories[i], err = service.liveStory(stories[i]); err != nil {
+			return nil, domain.Metadata{}, err
+		}
+	}
+	return stories, meta, nil
+}
+
+// liveStory replaces draft content of story with its published
+// revision, unpublished story is unknown to other than its author
+func (service *StoryService) liveStory(story domain.Story) (domain.Story, error) {
+	if !story.IsPublished() {
+		return domain.Story{}, domain.ErrUnknownResource.WithMessage("story is not published")
+	}
+
+	revision, err := service.revisionRepo.GetRevision(story.ID, story.PublishedRevision)
+	if err != nil {
+		return domain.Story{}, err
+	}
+	story.Title = revision.Title
+	story.Subtitle = revision.Subtitle
+	story.Body = revision.Body
+	story.ReadingTime = ReadingTime(revision.Body)
+	return story, nil
+}
+
+// CreateStory creates new draft story written by author
+func (service *StoryService) CreateStory(authorID uint64, story domain.Story) (result domain.Story, err error) {
+	err = service.transaction(func(txService *StoryService) (err error) {
+		result, err = txService.createStory(authorID, story)
+		return
+	})
+	return
+}
+
+func (service *StoryService) createStory(authorID uint64, story domain.Story) (domain.Story, error) {
+	story.Title = strings.TrimSpace(story.Title)
+	if len(story.Title) == 0 {
+		return domain.Story{}, domain.ErrBadParameters.WithFieldMessagef("title", domain.DetailRequired, "story title is required")
+	}
+
+	story.ID = 0
+	story.AuthorID = authorID
+	story.Slug = Slugify(story.Title)
+	story.Status = domain.StoryStatusDraft
+	story.ReadingTime = ReadingTime(story.Body)
+	story.PublishedAt = nil
+	story.PublishedRevision = 0
+
+	created, err := service.storyRepo.InsertOne(story)
+	if err != nil {
+		return domain.Story{}, err
+	}
+	if err := service.saveRevision(created); err != nil {
+		return domain.Story{}, err
+	}
+	return created, nil
+}
+
+// UpdateStory updates title, subtitle and body of author's story
+func (service *StoryService) UpdateStory(authorID uint64, storyID uint64, story domain.Story) (result domain.Story, err error) {
+	err = service.transaction(func(txService *StoryService) (err error) {
+		result, err = txService.updateStory(authorID, storyID, story)
+		return
+	})
+	return
+}
+
+func (service *StoryService) updateStory(authorID uint64, storyID uint64, story domain.Story) (domain.Story, error) {
+	current, err := service.getAuthorStory(authorID, storyID)
+	if err != nil {
+		return domain.Story{}, err
+	}
+
+	if title := strings.TrimSpace(story.Title); len(title) > 0 {
+		current.Title = title
+	}
+	if len(story.Subtitle) > 0 {
+		current.Subtitle = story.Subtitle
+	}
+	if len(story.Body) > 0 {
+		current.Body = story.Body
+		current.ReadingTime = ReadingTime(story.Body)
+	}
+
+	return service.saveDraft(current)
+}
+
+// DeleteStory removes author's story along with its revisions
+func (service *StoryService) DeleteStory(authorID uint64, storyID uint64) error {
+	return service.transaction(func(txService *StoryService) error {
+		return txService.deleteStory(authorID, storyID)
+	})
+}
+
+func (service *StoryService) deleteStory(authorID uint64, storyID uint64) error {
+	if _, err := service.getAuthorStory(authorID, storyID); err != nil {
+		return err
+	}
+	if err := service.revisionRepo.DeleteByStory(storyID); err != nil {
+		return err
+	}
+	return service.storyRepo.DeleteOne(storyID)
+}
+
+// PublishStory pins revision as live content of author's
+// story. Revision 0 pins the latest revision of the story.
+func (service *StoryService) PublishStory(authorID uint64, storyID uint64, revision int) (result domain.Story, err error) {
+	err = service.transaction(func(txService *StoryService) (err error) {
+		result, err = txService.publishStory(authorID, storyID, revision)
+		return
+	})
+	return
+}
+
+func (service *StoryService) publishStory(authorID uint64, storyID uint64, revision int) (domain.Story, error) {
+	story, err := service.getAuthorStory(authorID, storyID)
+	if err != nil {
+		return domain.Story{}, err
+	}
+
+	pinned, err := service.getRevision(storyID, revision)
+	if err != nil {
+		return domain.Story{}, err
+	}
+
+	story.Status = domain.StoryStatusPublished
+	story.PublishedRevision = pinned.Number
+	if story.PublishedAt == nil {
+		now := time.Now()
+		story.PublishedAt = &now
+	}
+
+	updated, err := service.storyRepo.UpdateStatus(storyID, story)
+	if err != nil {
+		return domain.Story{}, err
+	}
+	story.UpdatedAt = updated.UpdatedAt
+	return story, nil
+}
+
+// UnpublishStory reverts author's published story back to draft
+func (service *StoryService) UnpublishStory(authorID uint64, storyID uint64) (domain.Story, error) {
+	story, err := service.getAuthorStory(authorID, storyID)
+	if err != nil {
+		return domain.Story{}, err
+	}
+	if !story.IsPublished() {
+		return domain.Story{}, domain.ErrBadParameters.WithMessage("story is not published")
+	}
+
+	story.Status = domain.StoryStatusDraft
+	story.PublishedRevision = 0
+	story.PublishedAt = nil
+
+	updated, err := service.storyRepo.UpdateStatus(storyID, story)
+	if err != nil {
+		return domain.Story{}, err
+	}
+	story.UpdatedAt = updated.UpdatedAt
+	return story, nil
+}
+
+// ListRevisions returns paginated revisions of author's story, newest first
+func (service *StoryService) ListRevisions(authorID uint64, storyID uint64, page int, limit int) ([]domain.StoryRevision, domain.Metadata, error) {
+	if _, err := service.getAuthorStory(authorID, storyID); err != nil {
+		return nil, domain.Metadata{}, err
+	}
+	return service.revisionRepo.ListRevisions(storyID, page, limit)
+}
+
+// DiffRevisions returns line based difference from
+// revision number from to revision number to
+func (service *StoryService) DiffRevisions(authorID uint64, storyID uint64, from int, to int) (domain.RevisionDiff, error) {
+	if from < 1 || to < 1 {
+		return domain.RevisionDiff{}, domain.ErrBadParameters.WithMessage("revision number starts from 1")
+	}
+	if _, err := service.getAuthorStory(authorID, storyID); err != nil {
+		return domain.RevisionDiff{}, err
+	}
+
+	fromRevision, err := service.revisionRepo.GetRevision(storyID, from)
+	if err != nil {
+		return domain.RevisionDiff{}, err
+	}
+	toRevision, err := service.revisionRepo.GetRevision(storyID, to)
+	if err != nil {
+		return domain.RevisionDiff{}, err
+	}
+
+	return domain.RevisionDiff{
+		StoryID:  storyID,
+		From:     from,
+		To:       to,
+		Title:    diff.Lines(fromRevision.Title, toRevision.Title),
+		Subtitle: diff.Lines(fromRevision.Subtitle, toRevision.Subtitle),
+		Body:     diff.Lines(fromRevision.Body, toRevision.Body),
+	}, nil
+}
+
+// RestoreRevision rolls back draft of author's story to content of
+// given revision, saved as new revision. Live content is untouched.
+func (service *StoryService) RestoreRevision(authorID uint64, storyID uint64, revision int) (result domain.Story, err error) {
+	err = service.transaction(func(txService *StoryService) (err error) {
+		result, err = txService.restoreRevision(authorID, storyID, revision)
+		return
+	})
+	return
+}
+
+func (service *StoryService) restoreRevision(authorID uint64, storyID uint64, revision int) (domain.Story, error) {
+	if revision < 1 {
+		return domain.Story{}, domain.ErrBadParameters.WithFieldMessagef("revision", domain.DetailInvalid, "revision number starts from 1")
+	}
+
+	story, err := service.getAuthorStory(authorID, storyID)
+	if err != nil {
+		return domain.Story{}, err
+	}
+
+	restored, err := service.revisionRepo.GetRevision(storyID, revision)
+	if err != nil {
+		return domain.Story{}, err
+	}
+
+	story.Title = restored.Title
+	story.Subtitle = restored.Subtitle
+	story.Body = restored.Body
+	story.ReadingTime = ReadingTime(restored.Body)
+	return service.saveDraft(story)
+}
+
+// saveDraft updates the story working draft and saves it as new revision
+func (service *StoryService) saveDraft(story domain.Story) (domain.Story, error) {
+	updated, err := service.storyRepo.UpdateOne(story.ID, story)
+	if err != nil {
+		return domain.Story{}, err
+	}
+	if err := service.saveRevision(story); err != nil {
+		return domain.Story{}, err
+	}
+
+	story.UpdatedAt = updated.UpdatedAt
+	return story, nil
+}
+
+// saveRevision inserts story content as its next revision
+func (service *StoryService) saveRevision(story domain.Story) error {
+	_, err := service.revisionRepo.InsertRevision(domain.StoryRevision{
+		StoryID:  story.ID,
+		Title:    story.Title,
+		Subtitle: story.Subtitle,
+		Body:     story.Body,
+	})
+	return err
+}
+
+// getRevision fetches revision with given number,
+// or the latest revision of the story if number is 0
+func (service *StoryService) getRevision(storyID uint64, number int) (domain.StoryRevision, error) {
+	if number < 0 {
+		return domain.StoryRevision{}, domain.ErrBadParameters.WithFieldMessagef("revision", domain.DetailInvalid, "revision number starts from 1")
+	}
+	if number > 0 {
+		return service.revisionRepo.GetRevision(storyID, number)
+	}
+
+	revisions, _, err := service.revisionRepo.ListRevisions(storyID, 1, 1)
+	if err != nil {
+		return domain.StoryRevision{}, err
+	}
+	if len(revisions) == 0 {
+		return domain.StoryRevision{}, domain.ErrUnknownResource.WithMessage("story has no revision")
+	}
+	return revisions[0], nil
+}
+
+// getAuthorStory fetches story and ensures it is written by author
+func (service *StoryService) getAuthorStory(authorID uint64, storyID uint64) (domain.Story, error) {
+	story, err := service.storyRepo.GetByID(storyID)
+	if err != nil {
+		return domain.Story{}, err
+	}
+	if story.AuthorID != authorID {
+		return domain.Story{}, domain.ErrOperationNotSupported.WithMessage("only author can modify the story")
+	}
+	return story, nil
+}
+
+// Slugify converts title to lowercase, URL-safe words joined by dash
+func Slugify(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !(r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)))
+	})
+	return strings.Join(words, "-")
+}
+
+// ReadingTime estimates minutes needed to read body, at least 1 minute
+func ReadingTime(body string) int {
+	minutes := (len(strings.Fields(body)) + WordsPerMinute - 1) / WordsPerMinute
+	if minutes < 1 {
+		return 1
+	}
+	return minutes
+}
@@ -0,0 +1,358 @@
+package service
+
+import (
+	// import built-in libraries
+	"context"
+	"strings"
+	"testing"
+
+	// import third-party libraries
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/domain/mocks"
+)
+
+type TestSuite struct {
+	suite.Suite
+	Repository         *mocks.StoryRepository
+	RevisionRepository *mocks.StoryRevisionRepository
+	Service            domain.StoryService
+}
+
+func (tsuite *TestSuite) SetupTest() {
+	tsuite.Repository = new(mocks.StoryRepository)
+	tsuite.RevisionRepository = new(mocks.StoryRevisionRepository)
+	tsuite.Service = NewStoryService(tsuite.Repository, tsuite.RevisionRepository)
+}
+
+func (tsuite *TestSuite) AfterTest(_, _ string) {
+	tsuite.Repository.AssertExpectations(tsuite.T())
+	tsuite.RevisionRepository.AssertExpectations(tsuite.T())
+}
+
+func TestInit(t *testing.T) {
+	suite.Run(t, new(TestSuite))
+}
+
+// requireAppErrorCode asserts err is an *domain.AppError with given code
+func (tsuite *TestSuite) requireAppErrorCode(err error, code int) {
+	tsuite.Require().Error(err)
+	appErr, ok := err.(*domain.AppError)
+	tsuite.Require().True(ok, "expect *domain.AppError, got %T", err)
+	tsuite.Require().Equal(code, appErr.Code())
+}
+
+// revisionOf returns revision to be inserted when story is saved
+func revisionOf(story domain.Story) domain.StoryRevision {
+	return domain.StoryRevision{
+		StoryID:  story.ID,
+		Title:    story.Title,
+		Subtitle: story.Subtitle,
+		Body:     story.Body,
+	}
+}
+
+var mockStory = domain.Story{
+	ID:          7,
+	AuthorID:    1,
+	Title:       "Hello, Golumn!",
+	Body:        "Once upon a time",
+	Slug:        "hello-golumn-7",
+	Status:      domain.StoryStatusDraft,
+	ReadingTime: 1,
+}
+
+func (tsuite *TestSuite) TestShouldCreateDraftStory() {
+	insertStory := domain.Story{
+		AuthorID:    1,
+		Title:       "Hello, Golumn!",
+		Body:        "Once upon a time",
+		Slug:        "hello-golumn",
+		Status:      domain.StoryStatusDraft,
+		ReadingTime: 1,
+	}
+	tsuite.Repository.On("InsertOne", insertStory).Return(mockStory, nil).Once()
+	tsuite.RevisionRepository.On("InsertRevision", revisionOf(mockStory)).
+		Return(domain.StoryRevision{Number: 1}, nil).Once()
+
+	story, err := tsuite.Service.CreateStory(1, domain.Story{
+		Title:  " Hello, Golumn! ",
+		Body:   "Once upon a time",
+		Status: domain.StoryStatusPublished,
+	})
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(mockStory.ID, story.ID)
+}
+
+func (tsuite *TestSuite) TestShouldNotCreateUntitledStory() {
+	_, err := tsuite.Service.CreateStory(1, domain.Story{Body: "Once upon a time"})
+	tsuite.requireAppErrorCode(err, domain.InvalidParamCode)
+}
+
+func (tsuite *TestSuite) TestShouldUpdateStory() {
+	body := strings.Repeat("word ", 2*WordsPerMinute+1)
+	updateStory := mockStory
+	updateStory.Body = body
+	updateStory.ReadingTime = 3
+
+	tsuite.Repository.On("GetByID", mockStory.ID).Return(mockStory, nil).Once()
+	tsuite.Repository.On("UpdateOne", mockStory.ID, updateStory).Return(updateStory, nil).Once()
+	tsuite.RevisionRepository.On("InsertRevision", revisionOf(updateStory)).
+		Return(domain.StoryRevision{Number: 2}, nil).Once()
+
+	story, err := tsuite.Service.UpdateStory(1, mockStory.ID, domain.Story{Body: body})
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(mockStory.Title, story.Title)
+	tsuite.Require().Equal(3, story.ReadingTime)
+}
+
+func (tsuite *TestSuite) TestShouldNotUpdateOthersStory() {
+	tsuite.Repository.On("GetByID", mockStory.ID).Return(mockStory, nil).Once()
+
+	_, err := tsuite.Service.UpdateStory(2, mockStory.ID, domain.Story{Title: "Stolen"})
+	tsuite.requireAppErrorCode(err, domain.OperationUnsupportedCode)
+	tsuite.Repository.AssertNotCalled(tsuite.T(), "UpdateOne", mock.Anything, mock.Anything)
+}
+
+func (tsuite *TestSuite) TestShouldDeleteStory() {
+	tsuite.Repository.On("GetByID", mockStory.ID).Return(mockStory, nil).Once()
+	tsuite.RevisionRepository.On("DeleteByStory", mockStory.ID).Return(nil).Once()
+	tsuite.Repository.On("DeleteOne", mockStory.ID).Return(nil).Once()
+
+	err := tsuite.Service.DeleteStory(1, mockStory.ID)
+	tsuite.Require().NoError(err)
+}
+
+func (tsuite *TestSuite) TestShouldDeleteStoryInUnitOfWork() {
+	txRepository := new(mocks.StoryRepository)
+	txRevisionRepository := new(mocks.StoryRevisionRepository)
+	uow := new(mocks.UnitOfWork)
+	uow.On("Do", mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, fn func(domain.Repositories) error) error {
+			return fn(domain.Repositories{Stories: txRepository, Revisions: txRevisionRepository})
+		}).Once()
+
+	var deleted []string
+	txRepository.On("GetByID", mockStory.ID).Return(mockStory, nil).Once()
+	txRevisionRepository.On("DeleteByStory", mockStory.ID).Return(nil).Once().
+		Run(func(mock.Arguments) { deleted = append(deleted, "revisions") })
+	txRepository.On("DeleteOne", mockStory.ID).Return(nil).Once().
+		Run(func(mock.Arguments) { deleted = append(deleted, "story") })
+
+	service := NewStoryServiceWithUnitOfWork(tsuite.Repository, tsuite.RevisionRepository, uow)
+	tsuite.Require().NoError(service.DeleteStory(1, mockStory.ID))
+
+	// revisions are deleted before the story, on transaction bound repositories
+	tsuite.Require().Equal([]string{"revisions", "story"}, deleted)
+	uow.AssertExpectations(tsuite.T())
+	txRepository.AssertExpectations(tsuite.T())
+	txRevisionRepository.AssertExpectations(tsuite.T())
+}
+
+func (tsuite *TestSuite) TestShouldNotDeleteStoryWhenRevisionsFail() {
+	tsuite.Repository.On("GetByID", mockStory.ID).Return(mockStory, nil).Once()
+	tsuite.RevisionRepository.On("DeleteByStory", mockStory.ID).Return(domain.ErrInternalServer).Once()
+
+	err := tsuite.Service.DeleteStory(1, mockStory.ID)
+	tsuite.Require().Error(err)
+	tsuite.Repository.AssertNotCalled(tsuite.T(), "DeleteOne", mock.Anything)
+}
+
+func (tsuite *TestSuite) TestShouldPublishLatestRevision() {
+	latest := domain.StoryRevision{StoryID: mockStory.ID, Number: 3}
+
+	tsuite.Repository.On("GetByID", mockStory.ID).Return(mockStory, nil).Once()
+	tsuite.RevisionRepository.On("ListRevisions", mockStory.ID, 1, 1).
+		Return([]domain.StoryRevision{latest}, domain.Metadata{Total: 3, Page: 1, Limit: 1}, nil).Once()
+	tsuite.Repository.On("UpdateStatus", mockStory.ID, mock.MatchedBy(func(story domain.Story) bool {
+		return story.IsPublished() && story.PublishedRevision == 3 && story.PublishedAt != nil
+	})).Return(domain.Story{}, nil).Once()
+
+	story, err := tsuite.Service.PublishStory(1, mockStory.ID, 0)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(domain.StoryStatusPublished, story.Status)
+	tsuite.Require().Equal(3, story.PublishedRevision)
+}
+
+func (tsuite *TestSuite) TestShouldNotPublishUnknownRevision() {
+	tsuite.Repository.On("GetByID", mockStory.ID).Return(mockStory, nil).Once()
+	tsuite.RevisionRepository.On("GetRevision", mockStory.ID, 9).
+		Return(domain.StoryRevision{}, domain.ErrUnknownResource.WithMessage("not found")).Once()
+
+	_, err := tsuite.Service.PublishStory(1, mockStory.ID, 9)
+	tsuite.requireAppErrorCode(err, domain.UnknownResourceCode)
+	tsuite.Repository.AssertNotCalled(tsuite.T(), "UpdateStatus", mock.Anything, mock.Anything)
+}
+
+func (tsuite *TestSuite) TestShouldUnpublishStory() {
+	published := mockStory
+	published.Status = domain.StoryStatusPublished
+	published.PublishedRevision = 2
+
+	unpublished := mockStory
+	unpublished.Status = domain.StoryStatusDraft
+
+	tsuite.Repository.On("GetByID", mockStory.ID).Return(published, nil).Once()
+	tsuite.Repository.On("UpdateStatus", mockStory.ID, unpublished).Return(unpublished, nil).Once()
+
+	story, err := tsuite.Service.UnpublishStory(1, mockStory.ID)
+	tsuite.Require().NoError(err)
+	tsuite.Require().False(story.IsPublished())
+}
+
+func (tsuite *TestSuite) TestShouldNotUnpublishDraft() {
+	tsuite.Repository.On("GetByID", mockStory.ID).Return(mockStory, nil).Once()
+
+	_, err := tsuite.Service.UnpublishStory(1, mockStory.ID)
+	tsuite.requireAppErrorCode(err, domain.InvalidParamCode)
+}
+
+func (tsuite *TestSuite) TestShouldGetPublishedRevisionBySlug() {
+	published := mockStory
+	published.Status = domain.StoryStatusPublished
+	published.PublishedRevision = 1
+
+	tsuite.Repository.On("GetBySlug", mockStory.Slug).Return(published, nil).Once()
+	tsuite.RevisionRepository.On("GetRevision", mockStory.ID, 1).
+		Return(domain.StoryRevision{Number: 1, Title: "Live title", Body: "Live body"}, nil).Once()
+
+	story, err := tsuite.Service.GetStoryBySlug(mockStory.Slug)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal("Live title", story.Title)
+	tsuite.Require().Equal("Live body", story.Body)
+}
+
+func (tsuite *TestSuite) TestShouldNotGetDraftBySlug() {
+	tsuite.Repository.On("GetBySlug", mockStory.Slug).Return(mockStory, nil).Once()
+
+	_, err := tsuite.Service.GetStoryBySlug(mockStory.Slug)
+	tsuite.requireAppErrorCode(err, domain.UnknownResourceCode)
+}
+
+func (tsuite *TestSuite) TestShouldGetDraftOfAuthor() {
+	tsuite.Repository.On("GetByID", mockStory.ID).Return(mockStory, nil).Once()
+
+	story, err := tsuite.Service.GetStory(mockStory.AuthorID, mockStory.ID)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(mockStory, story)
+}
+
+func (tsuite *TestSuite) TestShouldNotGetDraftOfOtherAuthor() {
+	tsuite.Repository.On("GetByID", mockStory.ID).Return(mockStory, nil).Twice()
+
+	// neither other user nor anonymous caller gets the draft
+	for _, callerID := range []uint64{2, 0} {
+		_, err := tsuite.Service.GetStory(callerID, mockStory.ID)
+		tsuite.requireAppErrorCode(err, domain.UnknownResourceCode)
+	}
+}
+
+func (tsuite *TestSuite) TestShouldGetPublishedRevisionOfOtherAuthor() {
+	published := mockStory
+	published.Status = domain.StoryStatusPublished
+	published.PublishedRevision = 1
+
+	tsuite.Repository.On("GetByID", mockStory.ID).Return(published, nil).Once()
+	tsuite.RevisionRepository.On("GetRevision", mockStory.ID, 1).
+		Return(domain.StoryRevision{Number: 1, Title: "Live title", Body: "Live body"}, nil).Once()
+
+	story, err := tsuite.Service.GetStory(2, mockStory.ID)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal("Live title", story.Title)
+	tsuite.Require().Equal("Live body", story.Body)
+}
+
+func (tsuite *TestSuite) TestShouldListAllStoriesOfAuthor() {
+	meta := domain.Metadata{Total: 1, Page: 1, Limit: 20}
+	tsuite.Repository.On("FetchByAuthor", mockStory.AuthorID, 1, 20).
+		Return([]domain.Story{mockStory}, meta, nil).Once()
+
+	stories, _, err := tsuite.Service.ListAuthorStories(mockStory.AuthorID, mockStory.AuthorID, 1, 20)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal([]domain.Story{mockStory}, stories)
+}
+
+func (tsuite *TestSuite) TestShouldListPublishedStoriesOfOtherAuthor() {
+	published := mockStory
+	published.Status = domain.StoryStatusPublished
+	published.PublishedRevision = 1
+
+	meta := domain.Metadata{Total: 1, Page: 1, Limit: 20}
+	tsuite.Repository.On("FetchPublishedByAuthor", mockStory.AuthorID, 1, 20).
+		Return([]domain.Story{published}, meta, nil).Once()
+	tsuite.RevisionRepository.On("GetRevision", mockStory.ID, 1).
+		Return(domain.StoryRevision{Number: 1, Title: "Live title", Body: "Live body"}, nil).Once()
+
+	stories, _, err := tsuite.Service.ListAuthorStories(0, mockStory.AuthorID, 1, 20)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(stories, 1)
+	tsuite.Require().Equal("Live body", stories[0].Body)
+	tsuite.Repository.AssertNotCalled(tsuite.T(), "FetchByAuthor", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (tsuite *TestSuite) TestShouldDiffRevisions() {
+	tsuite.Repository.On("GetByID", mockStory.ID).Return(mockStory, nil).Once()
+	tsuite.RevisionRepository.On("GetRevision", mockStory.ID, 1).
+		Return(domain.StoryRevision{Number: 1, Title: "Title", Body: "a\nb"}, nil).Once()
+	tsuite.RevisionRepository.On("GetRevision", mockStory.ID, 2).
+		Return(domain.StoryRevision{Number: 2, Title: "Title", Body: "a\nc"}, nil).Once()
+
+	revisionDiff, err := tsuite.Service.DiffRevisions(1, mockStory.ID, 1, 2)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal([]domain.DiffLine{{Operation: domain.DiffEqual, Text: "Title"}}, revisionDiff.Title)
+	tsuite.Require().Equal([]domain.DiffLine{
+		{Operation: domain.DiffEqual, Text: "a"},
+		{Operation: domain.DiffDelete, Text: "b"},
+		{Operation: domain.DiffInsert, Text: "c"},
+	}, revisionDiff.Body)
+}
+
+func (tsuite *TestSuite) TestShouldRestoreRevision() {
+	restored := domain.StoryRevision{StoryID: mockStory.ID, Number: 1, Title: "Old title", Body: "Old body"}
+	restoredStory := mockStory
+	restoredStory.Title = restored.Title
+	restoredStory.Body = restored.Body
+
+	tsuite.Repository.On("GetByID", mockStory.ID).Return(mockStory, nil).Once()
+	tsuite.RevisionRepository.On("GetRevision", mockStory.ID, 1).Return(restored, nil).Once()
+	tsuite.Repository.On("UpdateOne", mockStory.ID, restoredStory).Return(restoredStory, nil).Once()
+	tsuite.RevisionRepository.On("InsertRevision", revisionOf(restoredStory)).
+		Return(domain.StoryRevision{Number: 4}, nil).Once()
+
+	story, err := tsuite.Service.RestoreRevision(1, mockStory.ID, 1)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal("Old title", story.Title)
+}
+
+func (tsuite *TestSuite) TestShouldRestoreRevisionOfEmptyBody() {
+	restored := domain.StoryRevision{StoryID: mockStory.ID, Number: 1, Title: "Old title"}
+	restoredStory := mockStory
+	restoredStory.Title, restoredStory.Subtitle = restored.Title, ""
+	restoredStory.Body, restoredStory.ReadingTime = "", ReadingTime("")
+
+	tsuite.Repository.On("GetByID", mockStory.ID).Return(mockStory, nil).Once()
+	tsuite.RevisionRepository.On("GetRevision", mockStory.ID, 1).Return(restored, nil).Once()
+	tsuite.Repository.On("UpdateOne", mockStory.ID, restoredStory).Return(restoredStory, nil).Once()
+	tsuite.RevisionRepository.On("InsertRevision", revisionOf(restoredStory)).
+		Return(domain.StoryRevision{Number: 4}, nil).Once()
+
+	// stored story is emptied as the restored revision
+	story, err := tsuite.Service.RestoreRevision(1, mockStory.ID, 1)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Empty(story.Body)
+}
+
+func (tsuite *TestSuite) TestSlugify() {
+	tsuite.Require().Equal("hello-golumn", Slugify("Hello, Golumn!"))
+	tsuite.Require().Equal("go-1-14-released", Slugify("  Go 1.14 -- released  "))
+	tsuite.Require().Equal("", Slugify("你好"))
+}
+
+func (tsuite *TestSuite) TestReadingTime() {
+	tsuite.Require().Equal(1, ReadingTime(""))
+	tsuite.Require().Equal(1, ReadingTime(strings.Repeat("word ", WordsPerMinute)))
+	tsuite.Require().Equal(2, ReadingTime(strings.Repeat("word ", WordsPerMinute+1)))
+}
@@ -0,0 +1,278 @@
+package http
+
+import (
+	// import built-in libraries
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/lib/middleware"
+	"github.com/iqdf/golumn-story-service/lib/publicid"
+	"github.com/iqdf/golumn-story-service/lib/validation"
+)
+
+// UserHandler represents the http handler for user
+type UserHandler struct {
+	UserService domain.UserService
+
+	// OwnerRoutes enables /users/{id} routes, which serve only the
+	// account owner, i.e. every route but profile and sign up. They
+	// require the principal (see middleware.WithPrincipal) to be set
+	// by authentication middleware, leave it off until it is mounted.
+	OwnerRoutes bool
+}
+
+// NewUserHandler registers user endpoints to the given mux,
+// {id} is public id of user (see publicid package)
+//
+//	GET    /@{username}
+//	POST   /users
+//
+// and owner routes, see UserHandler.OwnerRoutes
+//
+//	PATCH  /users/{id}
+//	DELETE /users/{id}
+//	POST   /users/{id}/restore
+//	GET    /users/{id}/export?format=json|zip
+//	POST   /users/{id}/follow/{username}
+//	DELETE /users/{id}/follow/{username}
+func NewUserHandler(mux *http.ServeMux, userService domain.UserService) *UserHandler {
+	return NewUserHandlerWithErrorRenderer(mux, userService, middleware.NewErrorRenderer(nil, false))
+}
+
+// NewUserHandlerWithErrorRenderer registers user endpoints
+// to the given mux, their errors are rendered by renderer
+func NewUserHandlerWithErrorRenderer(mux *http.ServeMux, userService domain.UserService, renderer *middleware.ErrorRenderer) *UserHandler {
+	handler := &UserHandler{UserService: userService}
+
+	// ServeMux cannot match path prefix "/@" other than
+	// through the root pattern which catches unmatched paths
+	mux.HandleFunc("/", renderer.Handle(handler.GetUserProfile))
+	mux.HandleFunc("/users", renderer.Handle(handler.CreateUser))
+	mux.HandleFunc("/users/", renderer.Handle(handler.routeUser))
+	return handler
+}
+
+// routeUser dispatches /users/{id}[/restore|/export|/follow/{username}] requests
+func (handler *UserHandler) routeUser(w http.ResponseWriter, r *http.Request) error {
+	if !handler.OwnerRoutes {
+		return domain.ErrUnknownResource.WithMessagef("no route for %v", r.URL.Path)
+	}
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/users/"), "/"), "/")
+
+	userID, err := publicid.Decode(segments[0])
+	if err != nil {
+		return domain.ErrBadParameters.WithFieldMessagef("id", domain.DetailInvalid, "invalid user id %q", segments[0])
+	}
+
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodPatch:
+		return handler.UpdateUsername(w, r, userID)
+	case len(segments) == 1 && r.Method == http.MethodDelete:
+		return handler.DeleteUser(w, r, userID)
+	case len(segments) == 2 && segments[1] == "restore" && r.Method == http.MethodPost:
+		return handler.RestoreUser(w, r, userID)
+	case len(segments) == 2 && segments[1] == "export" && r.Method == http.MethodGet:
+		return handler.ExportUserData(w, r, userID)
+	case len(segments) == 3 && segments[1] == "follow" && r.Method == http.MethodPost:
+		return handler.FollowUser(w, r, userID, segments[2])
+	case len(segments) == 3 && segments[1] == "follow" && r.Method == http.MethodDelete:
+		return handler.UnfollowUser(w, r, userID, segments[2])
+	case len(segments) == 1:
+		return methodNotAllowed(w, r, http.MethodPatch, http.MethodDelete)
+	case len(segments) == 2 && segments[1] == "restore":
+		return methodNotAllowed(w, r, http.MethodPost)
+	case len(segments) == 2 && segments[1] == "export":
+		return methodNotAllowed(w, r, http.MethodGet)
+	case len(segments) == 3 && segments[1] == "follow":
+		return methodNotAllowed(w, r, http.MethodPost, http.MethodDelete)
+	default:
+		return domain.ErrUnknownResource.WithMessagef("no route for %v", r.URL.Path)
+	}
+}
+
+// GetUserProfile handles GET /@{username}
+func (handler *UserHandler) GetUserProfile(w http.ResponseWriter, r *http.Request) error {
+	if !strings.HasPrefix(r.URL.Path, "/@") {
+		return domain.ErrUnknownResource.WithMessagef("no route for %v", r.URL.Path)
+	}
+	if r.Method != http.MethodGet {
+		return methodNotAllowed(w, r, http.MethodGet)
+	}
+
+	username := strings.TrimPrefix(r.URL.Path, "/@")
+	user, err := handler.UserService.GetUserProfileContext(r.Context(), username)
+	if err != nil {
+		return err
+	}
+	user.ID, user.Email = 0, "" // owner only fields
+	writeJSON(w, http.StatusOK, user)
+	return nil
+}
+
+// CreateUser handles POST /users
+func (handler *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return methodNotAllowed(w, r, http.MethodPost)
+	}
+
+	var user domain.User
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		return domain.ErrBadParameters.WithMessage("malformed user json body")
+	}
+	if err := validation.ValidateUser(user); err != nil {
+		return err
+	}
+
+	user, err := handler.UserService.CreateUserContext(r.Context(), user)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusCreated, user)
+	return nil
+}
+
+// UpdateUsername handles PATCH /users/{id}
+func (handler *UserHandler) UpdateUsername(w http.ResponseWriter, r *http.Request, userID uint64) error {
+	if err := authorizeOwner(r, userID); err != nil {
+		return err
+	}
+	var user domain.User
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		return domain.ErrBadParameters.WithMessage("malformed user json body")
+	}
+	if err := validation.ValidateUsername(user.Username); err != nil {
+		return err
+	}
+
+	user, err := handler.UserService.UpdateUsernameContext(r.Context(), userID, user)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, user)
+	return nil
+}
+
+// DeleteUser handles DELETE /users/{id}
+func (handler *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request, userID uint64) error {
+	if err := authorizeOwner(r, userID); err != nil {
+		return err
+	}
+	if err := handler.UserService.DeleteUserContext(r.Context(), userID); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// RestoreUser handles POST /users/{id}/restore
+func (handler *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request, userID uint64) error {
+	if err := authorizeOwner(r, userID); err != nil {
+		return err
+	}
+	user, err := handler.UserService.RestoreUserContext(r.Context(), userID)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, user)
+	return nil
+}
+
+// ExportUserData handles GET /users/{id}/export, format defaults to
+// json. Response is streamed, once started it can only be aborted.
+func (handler *UserHandler) ExportUserData(w http.ResponseWriter, r *http.Request, userID uint64) error {
+	if err := authorizeOwner(r, userID); err != nil {
+		return err
+	}
+	format := domain.ExportFormat(r.URL.Query().Get("format"))
+	if len(format) == 0 {
+		format = domain.ExportFormatJSON
+	}
+
+	export := &exportWriter{ResponseWriter: w, format: format}
+	err := handler.UserService.ExportUserDataContext(r.Context(), userID, format, export)
+	if err != nil && export.started {
+		// error response cannot follow partial archive, abort
+		// the response so that client does not take it as complete
+		panic(http.ErrAbortHandler)
+	}
+	return err
+}
+
+// exportWriter writes headers of export archive
+// on first write, leaving error response intact
+type exportWriter struct {
+	http.ResponseWriter
+	format  domain.ExportFormat
+	started bool
+}
+
+func (export *exportWriter) Write(data []byte) (int, error) {
+	if !export.started {
+		export.started = true
+		contentType := "application/json; charset=utf-8"
+		if export.format == domain.ExportFormatZip {
+			contentType = "application/zip"
+		}
+		export.Header().Set("Content-Type", contentType)
+		export.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="user-data.%s"`, export.format))
+		export.WriteHeader(http.StatusOK)
+	}
+	return export.ResponseWriter.Write(data)
+}
+
+// FollowUser handles POST /users/{id}/follow/{username}
+func (handler *UserHandler) FollowUser(w http.ResponseWriter, r *http.Request, userID uint64, username string) error {
+	if err := authorizeOwner(r, userID); err != nil {
+		return err
+	}
+	user, err := handler.UserService.FollowUserContext(r.Context(), userID, username)
+	if err != nil {
+		return err
+	}
+	user.ID, user.Email = 0, "" // owner only fields
+	writeJSON(w, http.StatusOK, user)
+	return nil
+}
+
+// UnfollowUser handles DELETE /users/{id}/follow/{username}
+func (handler *UserHandler) UnfollowUser(w http.ResponseWriter, r *http.Request, userID uint64, username string) error {
+	if err := authorizeOwner(r, userID); err != nil {
+		return err
+	}
+	user, err := handler.UserService.UnfollowUserContext(r.Context(), userID, username)
+	if err != nil {
+		return err
+	}
+	user.ID, user.Email = 0, "" // owner only fields
+	writeJSON(w, http.StatusOK, user)
+	return nil
+}
+
+// authorizeOwner checks request is authenticated as user of userID
+func authorizeOwner(r *http.Request, userID uint64) error {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		return domain.ErrAuthenticationFail.WithMessage("authentication required")
+	}
+	if principal != userID {
+		return domain.ErrOperationNotSupported.WithMessage("only owner can access the account")
+	}
+	return nil
+}
+
+// methodNotAllowed sets Allow header to allowed methods
+// of the resource, and returns error of r.Method
+func methodNotAllowed(w http.ResponseWriter, r *http.Request, allowed ...string) error {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	return domain.ErrMethodNotAllowed.WithMessagef("method %v not allowed", r.Method)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
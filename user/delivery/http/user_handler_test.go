@@ -0,0 +1,281 @@
+package http
+
+import (
+	// import built-in libraries
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	// import third-party libraries
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/domain/mocks"
+	"github.com/iqdf/golumn-story-service/lib/middleware"
+	"github.com/iqdf/golumn-story-service/lib/publicid"
+	"github.com/iqdf/golumn-story-service/lib/validation"
+)
+
+type TestSuite struct {
+	suite.Suite
+	Mux     *http.ServeMux
+	Service *mocks.UserService
+	Handler *UserHandler
+}
+
+func (tsuite *TestSuite) SetupTest() {
+	tsuite.Mux = http.NewServeMux()
+	tsuite.Service = new(mocks.UserService)
+	renderer := middleware.NewErrorRenderer(log.New(ioutil.Discard, "", 0), false)
+	tsuite.Handler = NewUserHandlerWithErrorRenderer(tsuite.Mux, tsuite.Service, renderer)
+	tsuite.Handler.OwnerRoutes = true
+}
+
+func (tsuite *TestSuite) AfterTest(_, _ string) {
+	tsuite.Service.AssertExpectations(tsuite.T())
+}
+
+func TestInit(t *testing.T) {
+	suite.Run(t, new(TestSuite))
+}
+
+// serve performs request against the handler mux
+func (tsuite *TestSuite) serve(method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	tsuite.Mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// serveAs performs request authenticated as user of userID
+func (tsuite *TestSuite) serveAs(userID uint64, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req = req.WithContext(middleware.WithPrincipal(req.Context(), userID))
+	rec := httptest.NewRecorder()
+	tsuite.Mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// requireError asserts response carries error envelope of appErr
+func (tsuite *TestSuite) requireError(rec *httptest.ResponseRecorder, appErr *domain.AppError) {
+	var respErr middleware.ErrorResponse
+	tsuite.Require().Equal(appErr.HTTPCode(), rec.Code)
+	tsuite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &respErr))
+	tsuite.Require().Equal(appErr.Code(), respErr.Code)
+	tsuite.Require().Equal(appErr.Name(), respErr.Name)
+}
+
+var mockUser = domain.User{
+	ID:       1,
+	Email:    "UserZero-email@example.com",
+	Username: "UserZero",
+	Name:     "Name-UserZero",
+}
+
+func (tsuite *TestSuite) TestShouldGetUserProfile() {
+	tsuite.Service.On("GetUserProfileContext", mock.Anything, mockUser.Username).Return(mockUser, nil).Once()
+
+	rec := tsuite.serve(http.MethodGet, "/@"+mockUser.Username, "")
+	tsuite.Require().Equal(http.StatusOK, rec.Code)
+
+	var user domain.User
+	tsuite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &user))
+	tsuite.Require().Equal(mockUser.Username, user.Username)
+	tsuite.Require().Empty(user.Email)
+}
+
+func (tsuite *TestSuite) TestShouldPassRequestContext() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tsuite.Service.On("GetUserProfileContext", ctx, mockUser.Username).
+		Return(domain.User{}, domain.ErrRequestCancelled.Wrap(ctx.Err(), "cancelled")).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/@"+mockUser.Username, nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	tsuite.Mux.ServeHTTP(rec, req)
+	tsuite.requireError(rec, domain.ErrRequestCancelled)
+	tsuite.Require().Equal(domain.StatusClientClosedRequest, rec.Code)
+}
+
+func (tsuite *TestSuite) TestShouldNotGetUnknownUserProfile() {
+	tsuite.Service.On("GetUserProfileContext", mock.Anything, "nobody").
+		Return(domain.User{}, domain.ErrUnknownResource.WithMessage("not found")).Once()
+
+	rec := tsuite.serve(http.MethodGet, "/@nobody", "")
+	tsuite.requireError(rec, domain.ErrUnknownResource)
+}
+
+func (tsuite *TestSuite) TestShouldCreateUser() {
+	body := `{"email":"UserZero-email@example.com","username":"UserZero","name":"Name-UserZero"}`
+	newUser := domain.User{Email: mockUser.Email, Username: mockUser.Username, Name: mockUser.Name}
+	tsuite.Service.On("CreateUserContext", mock.Anything, newUser).Return(mockUser, nil).Once()
+
+	rec := tsuite.serve(http.MethodPost, "/users", body)
+	tsuite.Require().Equal(http.StatusCreated, rec.Code)
+
+	var user domain.User
+	tsuite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &user))
+	tsuite.Require().Equal(mockUser.ID, user.ID)
+
+	// owner sees public id, never the database key
+	tsuite.Require().Contains(rec.Body.String(), `"id":"`+publicid.Encode(mockUser.ID)+`"`)
+}
+
+func (tsuite *TestSuite) TestShouldNotReturnExistingUserOnCreate() {
+	body := `{"email":"UserZero-email@example.com","username":"UserZero","name":"Name-UserZero"}`
+	tsuite.Service.On("CreateUserContext", mock.Anything, mock.Anything).
+		Return(domain.User{}, domain.ErrBadParameters.WithFieldMessagef("email", domain.DetailDuplicate, "email is already registered")).Once()
+
+	rec := tsuite.serve(http.MethodPost, "/users", body)
+	tsuite.requireError(rec, domain.ErrBadParameters)
+	tsuite.Require().NotContains(rec.Body.String(), publicid.Encode(mockUser.ID))
+}
+
+func (tsuite *TestSuite) TestShouldNotCreateUserWithMalformedBody() {
+	rec := tsuite.serve(http.MethodPost, "/users", "{")
+	tsuite.requireError(rec, domain.ErrBadParameters)
+}
+
+func (tsuite *TestSuite) TestShouldNotCreateInvalidUser() {
+	body := `{"email":"UserZero-email@example..com","username":"UserZero","name":"N"}`
+
+	rec := tsuite.serve(http.MethodPost, "/users", body)
+	tsuite.requireError(rec, domain.ErrBadParameters)
+
+	var respErr middleware.ErrorResponse
+	tsuite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &respErr))
+	tsuite.Require().Equal([]domain.ErrorDetail{
+		{Field: "email", Code: validation.RuleEmail, Message: "email must be a valid email address"},
+		{Field: "name", Code: validation.RuleMinLength, Message: "name must be at least 2 characters"},
+	}, respErr.Details)
+	tsuite.Service.AssertNotCalled(tsuite.T(), "GetOrCreateUserContext", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (tsuite *TestSuite) TestShouldUpdateUsername() {
+	tsuite.Service.On("UpdateUsernameContext", mock.Anything, mockUser.ID, domain.User{Username: "NewName"}).
+		Return(domain.User{ID: mockUser.ID, Username: "NewName"}, nil).Once()
+
+	rec := tsuite.serveAs(mockUser.ID, http.MethodPatch, "/users/"+publicid.Encode(mockUser.ID), `{"username":"NewName"}`)
+	tsuite.Require().Equal(http.StatusOK, rec.Code)
+}
+
+func (tsuite *TestSuite) TestShouldNotUpdateWithInvalidID() {
+	rec := tsuite.serveAs(mockUser.ID, http.MethodPatch, "/users/abc", `{"username":"NewName"}`)
+	tsuite.requireError(rec, domain.ErrBadParameters)
+}
+
+func (tsuite *TestSuite) TestShouldNotUpdateInvalidUsername() {
+	rec := tsuite.serveAs(mockUser.ID, http.MethodPatch, "/users/"+publicid.Encode(mockUser.ID), `{"username":""}`)
+	tsuite.requireError(rec, domain.ErrBadParameters)
+	tsuite.Require().Contains(rec.Body.String(), `"details":[{"field":"username","code":"required"`)
+	tsuite.Service.AssertNotCalled(tsuite.T(), "UpdateUsernameContext", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (tsuite *TestSuite) TestShouldDeleteUser() {
+	tsuite.Service.On("DeleteUserContext", mock.Anything, mockUser.ID).Return(nil).Once()
+
+	rec := tsuite.serveAs(mockUser.ID, http.MethodDelete, "/users/"+publicid.Encode(mockUser.ID), "")
+	tsuite.Require().Equal(http.StatusNoContent, rec.Code)
+}
+
+func (tsuite *TestSuite) TestShouldRestoreUser() {
+	tsuite.Service.On("RestoreUserContext", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+
+	rec := tsuite.serveAs(mockUser.ID, http.MethodPost, "/users/"+publicid.Encode(mockUser.ID)+"/restore", "")
+	tsuite.Require().Equal(http.StatusOK, rec.Code)
+}
+
+func (tsuite *TestSuite) TestShouldNotRestorePurgedUser() {
+	tsuite.Service.On("RestoreUserContext", mock.Anything, mockUser.ID).Return(domain.User{}, domain.ErrUnknownResource).Once()
+
+	rec := tsuite.serveAs(mockUser.ID, http.MethodPost, "/users/"+publicid.Encode(mockUser.ID)+"/restore", "")
+	tsuite.requireError(rec, domain.ErrUnknownResource)
+}
+
+func (tsuite *TestSuite) TestShouldExportUserData() {
+	tsuite.Service.On("ExportUserDataContext", mock.Anything, mockUser.ID, domain.ExportFormatZip, mock.Anything).
+		Return(func(ctx context.Context, userID uint64, format domain.ExportFormat, w io.Writer) error {
+			_, err := io.WriteString(w, "PK")
+			return err
+		}).Once()
+
+	rec := tsuite.serveAs(mockUser.ID, http.MethodGet, "/users/"+publicid.Encode(mockUser.ID)+"/export?format=zip", "")
+	tsuite.Require().Equal(http.StatusOK, rec.Code)
+	tsuite.Require().Equal("application/zip", rec.Header().Get("Content-Type"))
+	tsuite.Require().Contains(rec.Header().Get("Content-Disposition"), "user-data.zip")
+	tsuite.Require().Equal("PK", rec.Body.String())
+}
+
+func (tsuite *TestSuite) TestShouldNotExportUnknownUser() {
+	tsuite.Service.On("ExportUserDataContext", mock.Anything, mockUser.ID, domain.ExportFormatJSON, mock.Anything).
+		Return(domain.ErrUnknownResource).Once()
+
+	rec := tsuite.serveAs(mockUser.ID, http.MethodGet, "/users/"+publicid.Encode(mockUser.ID)+"/export", "")
+	tsuite.requireError(rec, domain.ErrUnknownResource)
+	tsuite.Require().Empty(rec.Header().Get("Content-Disposition"))
+}
+
+func (tsuite *TestSuite) TestShouldNotServeOwnerRoutesUnauthenticated() {
+	id := publicid.Encode(mockUser.ID)
+	tsuite.requireError(tsuite.serve(http.MethodPatch, "/users/"+id, `{"username":"NewName"}`), domain.ErrAuthenticationFail)
+	tsuite.requireError(tsuite.serve(http.MethodDelete, "/users/"+id, ""), domain.ErrAuthenticationFail)
+	tsuite.requireError(tsuite.serve(http.MethodPost, "/users/"+id+"/follow/UserOne", ""), domain.ErrAuthenticationFail)
+	tsuite.requireError(tsuite.serve(http.MethodDelete, "/users/"+id+"/follow/UserOne", ""), domain.ErrAuthenticationFail)
+}
+
+func (tsuite *TestSuite) TestShouldNotAccessOtherAccount() {
+	other := publicid.Encode(mockUser.ID + 1)
+	tsuite.requireError(tsuite.serveAs(mockUser.ID, http.MethodPatch, "/users/"+other, `{"username":"NewName"}`), domain.ErrOperationNotSupported)
+	tsuite.requireError(tsuite.serveAs(mockUser.ID, http.MethodDelete, "/users/"+other, ""), domain.ErrOperationNotSupported)
+	tsuite.requireError(tsuite.serveAs(mockUser.ID, http.MethodPost, "/users/"+other+"/follow/UserOne", ""), domain.ErrOperationNotSupported)
+	tsuite.requireError(tsuite.serveAs(mockUser.ID, http.MethodDelete, "/users/"+other+"/follow/UserOne", ""), domain.ErrOperationNotSupported)
+	tsuite.requireError(tsuite.serveAs(mockUser.ID, http.MethodPost, "/users/"+other+"/restore", ""), domain.ErrOperationNotSupported)
+	tsuite.requireError(tsuite.serveAs(mockUser.ID, http.MethodGet, "/users/"+other+"/export", ""), domain.ErrOperationNotSupported)
+}
+
+func (tsuite *TestSuite) TestShouldNotRouteOwnerRoutesByDefault() {
+	tsuite.Handler.OwnerRoutes = false
+	id := publicid.Encode(mockUser.ID)
+
+	tsuite.requireError(tsuite.serveAs(mockUser.ID, http.MethodPatch, "/users/"+id, `{"username":"NewName"}`), domain.ErrUnknownResource)
+	tsuite.requireError(tsuite.serveAs(mockUser.ID, http.MethodDelete, "/users/"+id, ""), domain.ErrUnknownResource)
+	tsuite.requireError(tsuite.serveAs(mockUser.ID, http.MethodPost, "/users/"+id+"/restore", ""), domain.ErrUnknownResource)
+	tsuite.requireError(tsuite.serveAs(mockUser.ID, http.MethodGet, "/users/"+id+"/export", ""), domain.ErrUnknownResource)
+	tsuite.requireError(tsuite.serveAs(mockUser.ID, http.MethodPost, "/users/"+id+"/follow/UserOne", ""), domain.ErrUnknownResource)
+}
+
+func (tsuite *TestSuite) TestShouldFollowUser() {
+	tsuite.Service.On("FollowUserContext", mock.Anything, mockUser.ID, "UserOne").
+		Return(domain.User{ID: 2, Username: "UserOne", FollowersCount: 1}, nil).Once()
+
+	rec := tsuite.serveAs(mockUser.ID, http.MethodPost, "/users/"+publicid.Encode(mockUser.ID)+"/follow/UserOne", "")
+	tsuite.Require().Equal(http.StatusOK, rec.Code)
+}
+
+func (tsuite *TestSuite) TestShouldUnfollowUser() {
+	tsuite.Service.On("UnfollowUserContext", mock.Anything, mockUser.ID, "UserOne").
+		Return(domain.User{}, domain.ErrBadParameters.WithMessage("not following")).Once()
+
+	rec := tsuite.serveAs(mockUser.ID, http.MethodDelete, "/users/"+publicid.Encode(mockUser.ID)+"/follow/UserOne", "")
+	tsuite.requireError(rec, domain.ErrBadParameters)
+}
+
+func (tsuite *TestSuite) TestShouldRejectUnsupportedMethod() {
+	rec := tsuite.serveAs(mockUser.ID, http.MethodGet, "/users/"+publicid.Encode(mockUser.ID), "")
+	tsuite.requireError(rec, domain.ErrMethodNotAllowed)
+	tsuite.Require().Equal(http.StatusMethodNotAllowed, rec.Code)
+	tsuite.Require().Equal("PATCH, DELETE", rec.Header().Get("Allow"))
+
+	rec = tsuite.serve(http.MethodPut, "/users", "")
+	tsuite.requireError(rec, domain.ErrMethodNotAllowed)
+	tsuite.Require().Equal("POST", rec.Header().Get("Allow"))
+}
@@ -0,0 +1,630 @@
+package memory
+
+import (
+	// import built-in libraries
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	// import third-party libraries
+	"github.com/pkg/errors"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+	repocommon "github.com/iqdf/golumn-story-service/lib/repository"
+)
+
+// DefaultLimit is the default and maximum
+// number of users returned by paginated query
+const DefaultLimit uint = 20
+
+// MaxInsertAttempts bounds number of insert attempts, each with newly
+// generated id, when the generated id collides with existing user
+const MaxInsertAttempts = 3
+
+// Default values of optional user fields,
+// as declared by UserDB gorm tags in MySQL.
+const (
+	DefaultProfileImgURL = "/icon/defaultpic"
+	DefaultLocation      = "Worldwide"
+	DefaultDescription   = "The author tend to keep air of mystery of him/herself"
+)
+
+// columnLength lists max length of user fields,
+// as declared by UserDB gorm tags in MySQL.
+var columnLength = []struct {
+	column string
+	length int
+	value  func(user *domain.User) string
+}{
+	{"email", 40, func(user *domain.User) string { return user.Email }},
+	{"username", 40, func(user *domain.User) string { return user.Username }},
+	{"name", 40, func(user *domain.User) string { return user.Name }},
+	{"profile_img_url", 128, func(user *domain.User) string { return user.ProfileImgURL }},
+	{"location", 40, func(user *domain.User) string { return user.Location }},
+	{"description", 256, func(user *domain.User) string { return user.Description }},
+	{"twitter_name", 20, func(user *domain.User) string { return user.TwitterName }},
+	{"facebook_name", 20, func(user *domain.User) string { return user.FacebookName }},
+}
+
+// UIntRandomizer ...
+type UIntRandomizer interface {
+	Uint32() uint32
+	Uint64() uint64
+}
+
+// followership is key of follower-followed relationship
+type followership struct {
+	followerID uint64
+	followedID uint64
+}
+
+// UserMemoryRepository implements domain.UserRepository in memory.
+// It enforces the same uniqueness of email and username, and returns
+// the same domain.AppError as UserMySQLRepository. Safe for concurrent use.
+type UserMemoryRepository struct {
+	mu           sync.RWMutex
+	rand         UIntRandomizer
+	lastID       uint64
+	users        map[uint64]domain.User
+	emails       map[string]uint64
+	usernames    map[string]uint64
+	followership map[followership]struct{}
+	deletedAt    map[uint64]time.Time
+}
+
+// NewUserMemoryRepository creates new empty UserMemoryRepository.
+// Like MySQL auto increment, zero id generated by rand is replaced
+// by next sequential id. Nil rand always generates sequential id.
+func NewUserMemoryRepository(rand UIntRandomizer) *UserMemoryRepository {
+	return &UserMemoryRepository{
+		rand:         rand,
+		users:        make(map[uint64]domain.User),
+		emails:       make(map[string]uint64),
+		usernames:    make(map[string]uint64),
+		followership: make(map[followership]struct{}),
+		deletedAt:    make(map[uint64]time.Time),
+	}
+}
+
+func errNotFound() error {
+	return domain.ErrUnknownResource.WithMessage(
+		"item not found with specified identifier/field")
+}
+
+// uniqueKey normalizes unique column value, as MySQL
+// default collation compares strings case-insensitively
+func uniqueKey(value string) string {
+	return strings.ToLower(value)
+}
+
+func (userRepo *UserMemoryRepository) generateID() uint64 {
+	var userID uint64
+	if userRepo.rand != nil {
+		userID = userRepo.rand.Uint64()
+	}
+	if userID == 0 {
+		userID = userRepo.lastID + 1
+	}
+	if userID > userRepo.lastID {
+		userRepo.lastID = userID
+	}
+	return userID
+}
+
+// activeUser returns user with given id unless not found or
+// soft deleted. Caller MUST hold the read or write lock.
+func (userRepo *UserMemoryRepository) activeUser(userID uint64) (domain.User, bool) {
+	if _, deleted := userRepo.deletedAt[userID]; deleted {
+		return domain.User{}, false
+	}
+	user, ok := userRepo.users[userID]
+	return user, ok
+}
+
+// checkColumns returns error if any field is longer than its column
+func checkColumns(user *domain.User) error {
+	for _, col := range columnLength {
+		if utf8.RuneCountInString(col.value(user)) > col.length {
+			return domain.ErrBadParameters.WithFieldMessagef(col.column, domain.DetailMaxLength, "data too long for %v field", col.column)
+		}
+	}
+	return nil
+}
+
+// GetByID ...
+func (userRepo *UserMemoryRepository) GetByID(userID uint64) (domain.User, error) {
+	return userRepo.GetByIDContext(context.Background(), userID)
+}
+
+// GetByIDContext ...
+func (userRepo *UserMemoryRepository) GetByIDContext(ctx context.Context, userID uint64) (domain.User, error) {
+	if err := repocommon.ContextAppError(ctx, "userrepo: find user by id fail"); err != nil {
+		return domain.User{}, err
+	}
+
+	userRepo.mu.RLock()
+	defer userRepo.mu.RUnlock()
+
+	user, ok := userRepo.activeUser(userID)
+	if !ok {
+		return domain.User{}, errNotFound()
+	}
+	return user, nil
+}
+
+// GetByEmail ...
+func (userRepo *UserMemoryRepository) GetByEmail(email string) (domain.User, error) {
+	return userRepo.GetByEmailContext(context.Background(), email)
+}
+
+// GetByEmailContext ...
+func (userRepo *UserMemoryRepository) GetByEmailContext(ctx context.Context, email string) (domain.User, error) {
+	if err := repocommon.ContextAppError(ctx, "userrepo: find user by email fail"); err != nil {
+		return domain.User{}, err
+	}
+
+	userRepo.mu.RLock()
+	defer userRepo.mu.RUnlock()
+
+	user, ok := userRepo.activeUser(userRepo.emails[uniqueKey(email)])
+	if !ok {
+		return domain.User{}, errNotFound()
+	}
+	return user, nil
+}
+
+// GetByUsername ...
+func (userRepo *UserMemoryRepository) GetByUsername(username string) (domain.User, error) {
+	return userRepo.GetByUsernameContext(context.Background(), username)
+}
+
+// GetByUsernameContext ...
+func (userRepo *UserMemoryRepository) GetByUsernameContext(ctx context.Context, username string) (domain.User, error) {
+	if err := repocommon.ContextAppError(ctx, "userrepo: find user by username fail"); err != nil {
+		return domain.User{}, err
+	}
+
+	userRepo.mu.RLock()
+	defer userRepo.mu.RUnlock()
+
+	user, ok := userRepo.activeUser(userRepo.usernames[uniqueKey(username)])
+	if !ok {
+		return domain.User{}, errNotFound()
+	}
+	return user, nil
+}
+
+// FetchMany returns paginated users filtered by non-empty fields
+// of userFilter: name prefix, location and username.
+func (userRepo *UserMemoryRepository) FetchMany(userFilter domain.User, page int, limit int) ([]domain.User, domain.Metadata, error) {
+	return userRepo.FetchManyContext(context.Background(), userFilter, page, limit)
+}
+
+// FetchManyContext is FetchMany aborted by ctx cancellation or deadline
+func (userRepo *UserMemoryRepository) FetchManyContext(ctx context.Context, userFilter domain.User, page int, limit int) ([]domain.User, domain.Metadata, error) {
+	if err := repocommon.ContextAppError(ctx, "userrepo: fetch many users fail"); err != nil {
+		return nil, domain.Metadata{}, err
+	}
+
+	userRepo.mu.RLock()
+	defer userRepo.mu.RUnlock()
+
+	matched := make([]domain.User, 0)
+	for _, user := range userRepo.users {
+		if _, deleted := userRepo.deletedAt[user.ID]; deleted {
+			continue
+		}
+		if len(userFilter.Name) > 0 && !strings.HasPrefix(uniqueKey(user.Name), uniqueKey(userFilter.Name)) {
+			continue
+		}
+		if len(userFilter.Location) > 0 && uniqueKey(user.Location) != uniqueKey(userFilter.Location) {
+			continue
+		}
+		if len(userFilter.Username) > 0 && uniqueKey(user.Username) != uniqueKey(userFilter.Username) {
+			continue
+		}
+		matched = append(matched, user)
+	}
+
+	users, meta := paginate(matched, page, limit)
+	return users, meta, nil
+}
+
+// InsertOne ...
+func (userRepo *UserMemoryRepository) InsertOne(user domain.User) (domain.User, error) {
+	return userRepo.InsertOneContext(context.Background(), user)
+}
+
+// InsertOneContext ...
+func (userRepo *UserMemoryRepository) InsertOneContext(ctx context.Context, user domain.User) (domain.User, error) {
+	if err := repocommon.ContextAppError(ctx, "userrepo: insert one user fail"); err != nil {
+		return domain.User{}, err
+	}
+
+	userRepo.mu.Lock()
+	defer userRepo.mu.Unlock()
+
+	user = domain.User{
+		Email:         user.Email,
+		Username:      user.Username,
+		Name:          user.Name,
+		ProfileImgURL: user.ProfileImgURL,
+		Location:      user.Location,
+		Description:   user.Description,
+	}
+	if err := checkColumns(&user); err != nil {
+		return domain.User{}, err
+	}
+	if _, ok := userRepo.emails[uniqueKey(user.Email)]; ok {
+		return domain.User{}, domain.ErrBadParameters.WithFieldMessagef("email", domain.DetailDuplicate, "conflict duplicate %v", user.Email)
+	}
+	if _, ok := userRepo.usernames[uniqueKey(user.Username)]; ok {
+		return domain.User{}, domain.ErrBadParameters.WithFieldMessagef("username", domain.DetailDuplicate, "conflict duplicate %v", user.Username)
+	}
+
+	for attempt := 1; ; attempt++ {
+		user.ID = userRepo.generateID()
+		if _, ok := userRepo.users[user.ID]; !ok {
+			break
+		}
+		if attempt >= MaxInsertAttempts {
+			return domain.User{}, domain.ErrInternalServer.Wrapf(
+				errors.Errorf("duplicate id %v", user.ID),
+				"userrepo: insert one user fail: generated id collides %d times", attempt)
+		}
+	}
+	if len(user.ProfileImgURL) == 0 {
+		user.ProfileImgURL = DefaultProfileImgURL
+	}
+	if len(user.Location) == 0 {
+		user.Location = DefaultLocation
+	}
+	if len(user.Description) == 0 {
+		user.Description = DefaultDescription
+	}
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+
+	userRepo.users[user.ID] = user
+	userRepo.emails[uniqueKey(user.Email)] = user.ID
+	userRepo.usernames[uniqueKey(user.Username)] = user.ID
+	return user, nil
+}
+
+// UpdateOne updates non-empty username, name, profile image,
+// location and description of user with given id
+func (userRepo *UserMemoryRepository) UpdateOne(userID uint64, user domain.User) (domain.User, error) {
+	return userRepo.UpdateOneContext(context.Background(), userID, user)
+}
+
+// UpdateOneContext is UpdateOne aborted by ctx cancellation or deadline
+func (userRepo *UserMemoryRepository) UpdateOneContext(ctx context.Context, userID uint64, user domain.User) (domain.User, error) {
+	if err := repocommon.ContextAppError(ctx, "userrepo: update one user fail"); err != nil {
+		return domain.User{}, err
+	}
+
+	userRepo.mu.Lock()
+	defer userRepo.mu.Unlock()
+
+	current, ok := userRepo.activeUser(userID)
+	if !ok {
+		return domain.User{}, errNotFound()
+	}
+
+	updated := current
+	if len(user.Username) > 0 {
+		updated.Username = user.Username
+	}
+	if len(user.Name) > 0 {
+		updated.Name = user.Name
+	}
+	if len(user.ProfileImgURL) > 0 {
+		updated.ProfileImgURL = user.ProfileImgURL
+	}
+	if len(user.Location) > 0 {
+		updated.Location = user.Location
+	}
+	if len(user.Description) > 0 {
+		updated.Description = user.Description
+	}
+	updated.UpdatedAt = time.Now()
+	if err := checkColumns(&updated); err != nil {
+		return domain.User{}, err
+	}
+
+	oldKey, newKey := uniqueKey(current.Username), uniqueKey(updated.Username)
+	if ownerID, ok := userRepo.usernames[newKey]; ok && ownerID != userID {
+		return domain.User{}, domain.ErrBadParameters.WithFieldMessagef("username", domain.DetailDuplicate, "conflict duplicate %v", updated.Username)
+	}
+	delete(userRepo.usernames, oldKey)
+	userRepo.usernames[newKey] = userID
+
+	userRepo.users[userID] = updated
+	return domain.User{
+		Username:      user.Username,
+		Name:          user.Name,
+		ProfileImgURL: user.ProfileImgURL,
+		Location:      user.Location,
+		Description:   user.Description,
+		UpdatedAt:     updated.UpdatedAt,
+	}, nil
+}
+
+// RelateUsers makes follower follows the followed user,
+// counters of both users are updated atomically.
+func (userRepo *UserMemoryRepository) RelateUsers(followedID uint64, followerID uint64) error {
+	return userRepo.RelateUsersContext(context.Background(), followedID, followerID)
+}
+
+// RelateUsersContext is RelateUsers aborted by ctx cancellation or deadline
+func (userRepo *UserMemoryRepository) RelateUsersContext(ctx context.Context, followedID uint64, followerID uint64) error {
+	if err := repocommon.ContextAppError(ctx, "userrepo: relate users fail"); err != nil {
+		return err
+	}
+
+	userRepo.mu.Lock()
+	defer userRepo.mu.Unlock()
+
+	key := followership{followerID: followerID, followedID: followedID}
+	if _, ok := userRepo.followership[key]; ok {
+		// same detail as primary key of followership table
+		return domain.ErrBadParameters.WithFieldMessagef("username", domain.DetailDuplicate, "conflict duplicate %v-%v", followerID, followedID)
+	}
+	return userRepo.updateFollowCounters(key, 1)
+}
+
+// UnrelateUsers makes follower unfollows the followed user,
+// counters of both users are updated atomically.
+func (userRepo *UserMemoryRepository) UnrelateUsers(followedID uint64, followerID uint64) error {
+	return userRepo.UnrelateUsersContext(context.Background(), followedID, followerID)
+}
+
+// UnrelateUsersContext is UnrelateUsers aborted by ctx cancellation or deadline
+func (userRepo *UserMemoryRepository) UnrelateUsersContext(ctx context.Context, followedID uint64, followerID uint64) error {
+	if err := repocommon.ContextAppError(ctx, "userrepo: unrelate users fail"); err != nil {
+		return err
+	}
+
+	userRepo.mu.Lock()
+	defer userRepo.mu.Unlock()
+
+	key := followership{followerID: followerID, followedID: followedID}
+	if _, ok := userRepo.followership[key]; !ok {
+		return errNotFound()
+	}
+	return userRepo.updateFollowCounters(key, -1)
+}
+
+// updateFollowCounters adds or removes relationship and adds delta
+// to counters of both users. Caller MUST hold the write lock.
+func (userRepo *UserMemoryRepository) updateFollowCounters(key followership, delta int) error {
+	followed, ok := userRepo.activeUser(key.followedID)
+	if !ok {
+		return errNotFound()
+	}
+	follower, ok := userRepo.activeUser(key.followerID)
+	if !ok {
+		return errNotFound()
+	}
+
+	if delta > 0 {
+		userRepo.followership[key] = struct{}{}
+	} else {
+		delete(userRepo.followership, key)
+	}
+
+	// re-read followed after writing follower
+	// in case user relates to him/herself
+	follower.FollowingCount += delta
+	userRepo.users[follower.ID] = follower
+	followed = userRepo.users[followed.ID]
+	followed.FollowersCount += delta
+	userRepo.users[followed.ID] = followed
+	return nil
+}
+
+// ListFollowers returns paginated users that follow user with given id
+func (userRepo *UserMemoryRepository) ListFollowers(userID uint64, page int, limit int) ([]domain.User, error) {
+	return userRepo.ListFollowersContext(context.Background(), userID, page, limit)
+}
+
+// ListFollowersContext is ListFollowers aborted by ctx cancellation or deadline
+func (userRepo *UserMemoryRepository) ListFollowersContext(ctx context.Context, userID uint64, page int, limit int) ([]domain.User, error) {
+	if err := repocommon.ContextAppError(ctx, "userrepo: list followership fail"); err != nil {
+		return nil, err
+	}
+
+	userRepo.mu.RLock()
+	defer userRepo.mu.RUnlock()
+
+	followers := make([]domain.User, 0)
+	for key := range userRepo.followership {
+		if follower, ok := userRepo.activeUser(key.followerID); ok && key.followedID == userID {
+			followers = append(followers, follower)
+		}
+	}
+
+	users, _ := paginate(followers, page, limit)
+	return users, nil
+}
+
+// ListFollowing returns paginated users followed by user with given id
+func (userRepo *UserMemoryRepository) ListFollowing(userID uint64, page int, limit int) ([]domain.User, error) {
+	return userRepo.ListFollowingContext(context.Background(), userID, page, limit)
+}
+
+// ListFollowingContext is ListFollowing aborted by ctx cancellation or deadline
+func (userRepo *UserMemoryRepository) ListFollowingContext(ctx context.Context, userID uint64, page int, limit int) ([]domain.User, error) {
+	if err := repocommon.ContextAppError(ctx, "userrepo: list followership fail"); err != nil {
+		return nil, err
+	}
+
+	userRepo.mu.RLock()
+	defer userRepo.mu.RUnlock()
+
+	following := make([]domain.User, 0)
+	for key := range userRepo.followership {
+		if followed, ok := userRepo.activeUser(key.followedID); ok && key.followerID == userID {
+			following = append(following, followed)
+		}
+	}
+
+	users, _ := paginate(following, page, limit)
+	return users, nil
+}
+
+// DeleteOne ...
+func (userRepo *UserMemoryRepository) DeleteOne(userID uint64) error {
+	return userRepo.DeleteOneContext(context.Background(), userID)
+}
+
+// DeleteOneContext soft deletes user, see PurgeOneContext
+func (userRepo *UserMemoryRepository) DeleteOneContext(ctx context.Context, userID uint64) error {
+	if err := repocommon.ContextAppError(ctx, "userrepo: delete one user fail"); err != nil {
+		return err
+	}
+
+	userRepo.mu.Lock()
+	defer userRepo.mu.Unlock()
+
+	if _, ok := userRepo.activeUser(userID); !ok {
+		return errNotFound()
+	}
+	userRepo.deletedAt[userID] = time.Now()
+	return nil
+}
+
+// RestoreOne ...
+func (userRepo *UserMemoryRepository) RestoreOne(userID uint64, deletedAfter time.Time) (domain.User, error) {
+	return userRepo.RestoreOneContext(context.Background(), userID, deletedAfter)
+}
+
+// RestoreOneContext undoes soft deletion of user deleted after deletedAfter
+func (userRepo *UserMemoryRepository) RestoreOneContext(ctx context.Context, userID uint64, deletedAfter time.Time) (domain.User, error) {
+	if err := repocommon.ContextAppError(ctx, "userrepo: restore one user fail"); err != nil {
+		return domain.User{}, err
+	}
+
+	userRepo.mu.Lock()
+	defer userRepo.mu.Unlock()
+
+	deletedAt, ok := userRepo.deletedAt[userID]
+	if !ok || !deletedAt.After(deletedAfter) {
+		return domain.User{}, errNotFound()
+	}
+	delete(userRepo.deletedAt, userID)
+	return userRepo.users[userID], nil
+}
+
+// ListDeleted ...
+func (userRepo *UserMemoryRepository) ListDeleted(deletedBefore time.Time, limit int) ([]domain.User, error) {
+	return userRepo.ListDeletedContext(context.Background(), deletedBefore, limit)
+}
+
+// ListDeletedContext returns users soft deleted before deletedBefore,
+// oldest deletion first. Non-positive limit defaults to DefaultLimit.
+func (userRepo *UserMemoryRepository) ListDeletedContext(ctx context.Context, deletedBefore time.Time, limit int) ([]domain.User, error) {
+	if err := repocommon.ContextAppError(ctx, "userrepo: list deleted users fail"); err != nil {
+		return nil, err
+	}
+
+	userRepo.mu.RLock()
+	defer userRepo.mu.RUnlock()
+
+	deleted := make([]domain.User, 0)
+	for userID, deletedAt := range userRepo.deletedAt {
+		if !deletedAt.After(deletedBefore) {
+			deleted = append(deleted, userRepo.users[userID])
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool {
+		deletedI, deletedJ := userRepo.deletedAt[deleted[i].ID], userRepo.deletedAt[deleted[j].ID]
+		if deletedI.Equal(deletedJ) {
+			return deleted[i].ID < deleted[j].ID
+		}
+		return deletedI.Before(deletedJ)
+	})
+
+	// limit is not bounded, see domain.UserRepository.ListDeleted
+	if limit < 1 {
+		limit = int(DefaultLimit)
+	}
+	if len(deleted) > limit {
+		deleted = deleted[:limit]
+	}
+	return deleted, nil
+}
+
+// PurgeOne ...
+func (userRepo *UserMemoryRepository) PurgeOne(userID uint64) error {
+	return userRepo.PurgeOneContext(context.Background(), userID)
+}
+
+// PurgeOneContext hard deletes soft deleted user and its followership,
+// counters of users it follows and users following it are decremented,
+// including those of deleted users.
+func (userRepo *UserMemoryRepository) PurgeOneContext(ctx context.Context, userID uint64) error {
+	if err := repocommon.ContextAppError(ctx, "userrepo: purge one user fail"); err != nil {
+		return err
+	}
+
+	userRepo.mu.Lock()
+	defer userRepo.mu.Unlock()
+
+	if _, ok := userRepo.deletedAt[userID]; !ok {
+		return errNotFound()
+	}
+
+	for key := range userRepo.followership {
+		if key.followerID == userID {
+			followed := userRepo.users[key.followedID]
+			followed.FollowersCount--
+			userRepo.users[key.followedID] = followed
+		}
+		if key.followedID == userID {
+			follower := userRepo.users[key.followerID]
+			follower.FollowingCount--
+			userRepo.users[key.followerID] = follower
+		}
+		if key.followerID == userID || key.followedID == userID {
+			delete(userRepo.followership, key)
+		}
+	}
+
+	user := userRepo.users[userID]
+	delete(userRepo.users, userID)
+	delete(userRepo.deletedAt, userID)
+	delete(userRepo.emails, uniqueKey(user.Email))
+	delete(userRepo.usernames, uniqueKey(user.Username))
+	return nil
+}
+
+// paginate sorts users by id and returns the requested page.
+// Non-positive page defaults to 1, limit is bounded to DefaultLimit.
+func paginate(users []domain.User, page int, limit int) ([]domain.User, domain.Metadata) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > int(DefaultLimit) {
+		limit = int(DefaultLimit)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	meta := domain.Metadata{Total: len(users), Page: page, Limit: limit}
+	start := (page - 1) * limit
+	if start > len(users) {
+		start = len(users)
+	}
+	end := start + limit
+	if end < len(users) {
+		meta.NextCursor = strconv.Itoa(page + 1)
+	} else {
+		end = len(users)
+	}
+	return users[start:end], meta
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	// import built-in libraries
+	"fmt"
+	"sync"
+	"testing"
+
+	// import third-party libraries
+	"github.com/stretchr/testify/require"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/user/repository/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.RunUserRepositorySuite(t, func(t *testing.T) domain.UserRepository {
+		return NewUserMemoryRepository(nil)
+	})
+}
+
+func TestConcurrentInsertKeepsUsernameUnique(t *testing.T) {
+	var (
+		userRepo = NewUserMemoryRepository(nil)
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		inserted int
+	)
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := userRepo.InsertOne(domain.User{
+				Email:    fmt.Sprintf("user%d@example.com", i),
+				Username: "same-username",
+			})
+			if err == nil {
+				mu.Lock()
+				inserted++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.Equal(t, 1, inserted)
+}
+
+// scriptedIDs generates ids in given order
+type scriptedIDs struct{ ids []uint64 }
+
+func (gen *scriptedIDs) Uint32() uint32 { return uint32(gen.Uint64()) }
+
+func (gen *scriptedIDs) Uint64() uint64 {
+	id := gen.ids[0]
+	gen.ids = gen.ids[1:]
+	return id
+}
+
+func TestInsertRetriesCollidedID(t *testing.T) {
+	userRepo := NewUserMemoryRepository(&scriptedIDs{ids: []uint64{7, 7, 8}})
+
+	_, err := userRepo.InsertOne(domain.User{Email: "user0@example.com", Username: "UserZero"})
+	require.NoError(t, err)
+	user, err := userRepo.InsertOne(domain.User{Email: "user1@example.com", Username: "UserOne"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(8), user.ID)
+}
+
+func TestInsertGivesUpCollidedID(t *testing.T) {
+	userRepo := NewUserMemoryRepository(&scriptedIDs{ids: []uint64{7, 7, 7, 7}})
+
+	_, err := userRepo.InsertOne(domain.User{Email: "user0@example.com", Username: "UserZero"})
+	require.NoError(t, err)
+	_, err = userRepo.InsertOne(domain.User{Email: "user1@example.com", Username: "UserOne"})
+	require.Error(t, err)
+	require.Equal(t, domain.InternalErrorCode, err.(*domain.AppError).Code())
+}
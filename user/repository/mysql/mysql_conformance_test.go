@@ -0,0 +1,25 @@
+package mysql
+
+import (
+	// import built-in libraries
+	"testing"
+
+	// import third-party libraries
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/lib/random"
+	"github.com/iqdf/golumn-story-service/user/repository/repotest"
+)
+
+// TestConformance runs repotest suite against a real MySQL database
+// given by GOLUMN_TEST_MYSQL_DSN env, for example
+// "root:secret@tcp(localhost:3306)/golumn_test?parseTime=true".
+func TestConformance(t *testing.T) {
+	repotest.RunDatabaseSuite(t, "mysql", "GOLUMN_TEST_MYSQL_DSN",
+		func(db *gorm.DB, ids *random.SnowflakeGenerator) domain.UserRepository {
+			return NewUserMySQLRepository(db, ids)
+		})
+}
@@ -2,9 +2,10 @@
 
 import (
 	// import built-in libraries
-	"fmt"
+	"context"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,9 +17,14 @@
 	repocommon "github.com/iqdf/golumn-story-service/lib/repository"
 )
 
-// DefaultLimit ...
+// DefaultLimit is the default and maximum
+// number of rows returned by paginated query
 const DefaultLimit uint = 20
 
+// MaxInsertAttempts bounds number of insert attempts, each with newly
+// generated id, when the generated id collides with existing user
+const MaxInsertAttempts = 3
+
 // UserDB ...
 type UserDB struct {
 	ID             uint64    `gorm:"PRIMARY_KEY"`
@@ -35,15 +41,12 @@ type UserDB struct {
 	FacebookName   string `gorm:"Type:VARCHAR(20)"`
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
+	DeletedAt      *time.Time `sql:"index"` // gorm soft delete
 }
 
-// NewUserDBWriter ...
+// NewUserDBWriter converts user to row to insert. Fields are
+// expected to be checked by validation.ValidateUser beforehand.
 func NewUserDBWriter(user domain.User) UserDB {
-	// TODO: check NOT NULL string ''
-	// TODO: check minimum length contraint
-	// email: RFC 3696 - Section 3
-	// username: 5 - 40
-	// name: 2 - 40
 	return UserDB{
 		Email:          user.Email,
 		Username:       user.Username,
@@ -63,6 +66,7 @@ func NewUserDBWriter(user domain.User) UserDB {
 // NewUserDBUpdater ...
 func NewUserDBUpdater(userID uint64, user domain.User) UserDB {
 	return UserDB{
+		Username:      user.Username,
 		Name:          user.Name,
 		ProfileImgURL: user.ProfileImgURL,
 		Location:      user.Location,
@@ -120,9 +124,29 @@ func (userDB *UserDB) User() domain.User {
 		FollowingCount: userDB.FollowingCount,
 		TwitterName:    userDB.TwitterName,
 		FacebookName:   userDB.FacebookName,
+		CreatedAt:      userDB.CreatedAt,
+		UpdatedAt:      userDB.UpdatedAt,
 	}
 }
 
+// FollowershipDB is the join table of follower-followed
+// relationship declared by UserDB.Followers
+type FollowershipDB struct {
+	FollowerID uint64 `gorm:"PRIMARY_KEY;AUTO_INCREMENT:false"`
+	FollowedID uint64 `gorm:"PRIMARY_KEY;AUTO_INCREMENT:false"`
+}
+
+// TableName ...
+func (followDB *FollowershipDB) TableName() string {
+	return "followership"
+}
+
+func init() {
+	// duplicate follow is reported as the followed username,
+	// the follower being the authenticated user
+	repocommon.RegisterPrimaryKeyField("followership", "username")
+}
+
 // UIntRandomizer ...
 type UIntRandomizer interface {
 	Uint32() uint32
@@ -132,6 +156,7 @@ type UIntRandomizer interface {
 // DBErrorConverter ...
 type DBErrorConverter interface {
 	AppError(error, string) error
+	IsPrimaryKeyCollision(error) bool
 }
 
 // UserMySQLRepository ...
@@ -154,76 +179,178 @@ func (userRepo *UserMySQLRepository) generateID() uint64 {
 	return userRepo.Rand.Uint64()
 }
 
+// dbContext returns DB session whose queries are bound to ctx
+func (userRepo *UserMySQLRepository) dbContext(ctx context.Context) *gorm.DB {
+	return repocommon.WithContext(ctx, userRepo.DB)
+}
+
+// appError converts db error to domain.AppError, in favor of
+// ctx error when query is aborted by ctx cancellation or deadline
+func (userRepo *UserMySQLRepository) appError(ctx context.Context, err error, message string) error {
+	return userRepo.ErrCvt.AppError(repocommon.ContextError(ctx, err), message)
+}
+
 // GetByID ...
 func (userRepo *UserMySQLRepository) GetByID(userID uint64) (domain.User, error) {
+	return userRepo.GetByIDContext(context.Background(), userID)
+}
+
+// GetByIDContext ...
+func (userRepo *UserMySQLRepository) GetByIDContext(ctx context.Context, userID uint64) (domain.User, error) {
 	var (
 		userDB = new(UserDB)
-		db     = userRepo.DB
+		db     = userRepo.dbContext(ctx)
 	)
 	// SELECT * FROM `users` WHERE (id = ?) ORDER BY `users`.`id` LIMIT 1
 	err := db.Where("id = ?", userID).First(&userDB).Error
-	appErr := userRepo.ErrCvt.AppError(err, "userrepo: find user by id fail")
+	appErr := userRepo.appError(ctx, err, "userrepo: find user by id fail")
 
 	return userDB.User(), appErr
 }
 
 // GetByEmail ...
 func (userRepo *UserMySQLRepository) GetByEmail(email string) (domain.User, error) {
+	return userRepo.GetByEmailContext(context.Background(), email)
+}
+
+// GetByEmailContext ...
+func (userRepo *UserMySQLRepository) GetByEmailContext(ctx context.Context, email string) (domain.User, error) {
 	var (
 		userDB = UserDB{Email: email}
-		db     = userRepo.DB
+		db     = userRepo.dbContext(ctx)
 	)
 	// SELECT * FROM `users` WHERE (email = ?) ORDER BY `users`.`id` LIMIT 1
 	err := db.Where("email = ?", email).First(&userDB).Error
-	fmt.Println("GetByEmail err", err, "Gorm cannot call Error()")
-	appErr := userRepo.ErrCvt.AppError(err, "userrepo: find user by email fail")
-	fmt.Println("GetByEmail AppErr", appErr)
+	appErr := userRepo.appError(ctx, err, "userrepo: find user by email fail")
 	return userDB.User(), appErr
 }
 
 // GetByUsername ...
 func (userRepo *UserMySQLRepository) GetByUsername(username string) (domain.User, error) {
+	return userRepo.GetByUsernameContext(context.Background(), username)
+}
+
+// GetByUsernameContext ...
+func (userRepo *UserMySQLRepository) GetByUsernameContext(ctx context.Context, username string) (domain.User, error) {
 	var (
 		userDB = new(UserDB)
-		db     = userRepo.DB
+		db     = userRepo.dbContext(ctx)
 	)
 	// SELECT * FROM `users` WHERE (username = ?) ORDER BY `users`.`id` LIMIT 1
 	err := db.Where("username = ?", username).First(&userDB).Error
-	appErr := userRepo.ErrCvt.AppError(err, "userrepo: find user by username fail")
+	appErr := userRepo.appError(ctx, err, "userrepo: find user by username fail")
 
 	return userDB.User(), appErr
 }
 
+// FetchMany returns paginated users filtered by non-empty fields
+// of userFilter: name prefix, location and username.
+func (userRepo *UserMySQLRepository) FetchMany(userFilter domain.User, page int, limit int) ([]domain.User, domain.Metadata, error) {
+	return userRepo.FetchManyContext(context.Background(), userFilter, page, limit)
+}
+
+// FetchManyContext is FetchMany aborted by ctx cancellation or deadline
+func (userRepo *UserMySQLRepository) FetchManyContext(ctx context.Context, userFilter domain.User, page int, limit int) ([]domain.User, domain.Metadata, error) {
+	var (
+		usersDB = make([]UserDB, 0)
+		total   int
+		db      = userRepo.dbContext(ctx).Model(&UserDB{})
+	)
+	offset, limit := pagination(page, limit)
+
+	if len(userFilter.Name) > 0 {
+		db = db.Where("name LIKE ?", escapeLike(userFilter.Name)+"%")
+	}
+	if len(userFilter.Location) > 0 {
+		db = db.Where("location = ?", userFilter.Location)
+	}
+	if len(userFilter.Username) > 0 {
+		db = db.Where("username = ?", userFilter.Username)
+	}
+
+	// SELECT count(*) FROM `users` WHERE (...)
+	if err := db.Count(&total).Error; err != nil {
+		appErr := userRepo.appError(ctx, err, "userrepo: count many users fail")
+		return nil, domain.Metadata{}, appErr
+	}
+
+	// SELECT * FROM `users` WHERE (...) ORDER BY `users`.`id` LIMIT (limit) OFFSET (offset)
+	err := db.Order("id").Offset(offset).Limit(limit).Find(&usersDB).Error
+	if err != nil {
+		appErr := userRepo.appError(ctx, err, "userrepo: fetch many users fail")
+		return nil, domain.Metadata{}, appErr
+	}
+
+	users := make([]domain.User, 0, len(usersDB))
+	for _, userDB := range usersDB {
+		users = append(users, userDB.User())
+	}
+
+	meta := domain.Metadata{Total: total, Page: offset/limit + 1, Limit: limit}
+	if offset+len(users) < total {
+		meta.NextCursor = strconv.Itoa(meta.Page + 1)
+	}
+	return users, meta, nil
+}
+
 // InsertOne ...
 func (userRepo *UserMySQLRepository) InsertOne(user domain.User) (domain.User, error) {
+	return userRepo.InsertOneContext(context.Background(), user)
+}
+
+// InsertOneContext inserts user with newly generated id. On primary key
+// collision, the insert is retried with regenerated id up to
+// MaxInsertAttempts, as it is not the client fault.
+func (userRepo *UserMySQLRepository) InsertOneContext(ctx context.Context, user domain.User) (domain.User, error) {
 	var (
 		userDB = NewUserDBWriter(user)
-		db     = userRepo.DB
+		db     = userRepo.dbContext(ctx)
 	)
-	userDB.ID = userRepo.generateID()
 
-	// INSERT INTO `users` (...) VALUES (...)
-	db = db.Create(&userDB)
-	if err := db.Error; err != nil || db.RowsAffected == 0 {
-		appErr := userRepo.ErrCvt.AppError(err, "userrepo: insert one user fail")
-		return domain.User{}, appErr
+	for attempt := 1; ; attempt++ {
+		userDB.ID = userRepo.generateID()
+
+		// INSERT INTO `users` (...) VALUES (...), in savepoint of ongoing
+		// PostgreSQL transaction to be retried after id collision
+		var rowsAffected int64
+		err := repocommon.Savepoint(db, "insert_user", func(tx *gorm.DB) error {
+			res := tx.Create(&userDB)
+			rowsAffected = res.RowsAffected
+			return res.Error
+		})
+		if err != nil && userRepo.ErrCvt.IsPrimaryKeyCollision(err) {
+			if attempt < MaxInsertAttempts {
+				continue
+			}
+			return domain.User{}, domain.ErrInternalServer.Wrapf(err,
+				"userrepo: insert one user fail: generated id collides %d times", attempt)
+		}
+		if err != nil || rowsAffected == 0 {
+			appErr := userRepo.appError(ctx, err, "userrepo: insert one user fail")
+			return domain.User{}, appErr
+		}
+		return userDB.User(), nil
 	}
-	return userDB.User(), nil
 }
 
 // UpdateOne ...
 func (userRepo *UserMySQLRepository) UpdateOne(userID uint64, user domain.User) (domain.User, error) {
+	return userRepo.UpdateOneContext(context.Background(), userID, user)
+}
+
+// UpdateOneContext ...
+func (userRepo *UserMySQLRepository) UpdateOneContext(ctx context.Context, userID uint64, user domain.User) (domain.User, error) {
 	var (
 		userDB = NewUserDBUpdater(userID, user)
-		db     = userRepo.DB
+		db     = userRepo.dbContext(ctx)
 	)
 
 	// UPDATE `users` SET location = (location), description = (description)
 	// WHERE id = (userID)
 	db = db.Set("gorm:association_save_reference", false)
 	db = db.Model(&UserDB{ID: userID}).Updates(userDB) // update attributes of a user row
-	if err := db.Error; err != nil || db.RowsAffected == 0 {
-		appErr := userRepo.ErrCvt.AppError(err, "userrepo: update one user fail")
+	if err := rowsAffectedError(db); err != nil {
+		appErr := userRepo.appError(ctx, err, "userrepo: update one user fail")
 		return domain.User{}, appErr
 	}
 
@@ -232,16 +359,276 @@ func (userRepo *UserMySQLRepository) UpdateOne(userID uint64, user domain.User)
 
 // DeleteOne ...
 func (userRepo *UserMySQLRepository) DeleteOne(userID uint64) error {
+	return userRepo.DeleteOneContext(context.Background(), userID)
+}
+
+// DeleteOneContext soft deletes user, see PurgeOneContext
+func (userRepo *UserMySQLRepository) DeleteOneContext(ctx context.Context, userID uint64) error {
 	var (
 		userDB = &UserDB{ID: userID}
-		db     = userRepo.DB
+		db     = userRepo.dbContext(ctx)
 	)
 
-	// DELETE FROM `users` WHERE id = ?
+	// UPDATE `users` SET `deleted_at`=? WHERE `users`.`deleted_at` IS NULL AND `users`.`id` = ?
 	db = db.Delete(&userDB)
-	if err := db.Error; err != nil || db.RowsAffected == 0 { // do you need to check?
-		appErr := userRepo.ErrCvt.AppError(err, "userrepo: delete one user fail")
+	if err := rowsAffectedError(db); err != nil {
+		appErr := userRepo.appError(ctx, err, "userrepo: delete one user fail")
 		return appErr
 	}
 	return nil
 }
+
+// RestoreOne ...
+func (userRepo *UserMySQLRepository) RestoreOne(userID uint64, deletedAfter time.Time) (domain.User, error) {
+	return userRepo.RestoreOneContext(context.Background(), userID, deletedAfter)
+}
+
+// RestoreOneContext undoes soft deletion of user deleted after deletedAfter
+func (userRepo *UserMySQLRepository) RestoreOneContext(ctx context.Context, userID uint64, deletedAfter time.Time) (domain.User, error) {
+	var (
+		userDB = new(UserDB)
+		db     = userRepo.dbContext(ctx)
+	)
+
+	err := repocommon.Transaction(db, func(tx *gorm.DB) error {
+		// UPDATE `users` SET `deleted_at` = NULL WHERE (id = ? AND deleted_at > ?)
+		res := tx.Unscoped().Model(&UserDB{}).Where("id = ? AND deleted_at > ?", userID, deletedAfter).
+			UpdateColumn("deleted_at", nil)
+		if err := rowsAffectedError(res); err != nil {
+			return err
+		}
+		// SELECT * FROM `users` WHERE `users`.`deleted_at` IS NULL AND ((id = ?)) ...
+		return tx.Where("id = ?", userID).First(userDB).Error
+	})
+	if err != nil {
+		return domain.User{}, userRepo.appError(ctx, err, "userrepo: restore one user fail")
+	}
+	return userDB.User(), nil
+}
+
+// ListDeleted ...
+func (userRepo *UserMySQLRepository) ListDeleted(deletedBefore time.Time, limit int) ([]domain.User, error) {
+	return userRepo.ListDeletedContext(context.Background(), deletedBefore, limit)
+}
+
+// ListDeletedContext returns users soft deleted before deletedBefore,
+// oldest deletion first. Non-positive limit defaults to DefaultLimit.
+func (userRepo *UserMySQLRepository) ListDeletedContext(ctx context.Context, deletedBefore time.Time, limit int) ([]domain.User, error) {
+	var (
+		usersDB = make([]UserDB, 0)
+		db      = userRepo.dbContext(ctx)
+	)
+	// limit is not bounded, see domain.UserRepository.ListDeleted
+	if limit < 1 {
+		limit = int(DefaultLimit)
+	}
+
+	// SELECT * FROM `users` WHERE (deleted_at IS NOT NULL AND deleted_at <= ?)
+	// ORDER BY deleted_at, id LIMIT (limit)
+	err := db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at <= ?", deletedBefore).
+		Order("deleted_at").Order("id").Limit(limit).Find(&usersDB).Error
+	if err != nil {
+		return nil, userRepo.appError(ctx, err, "userrepo: list deleted users fail")
+	}
+
+	users := make([]domain.User, 0, len(usersDB))
+	for _, userDB := range usersDB {
+		users = append(users, userDB.User())
+	}
+	return users, nil
+}
+
+// PurgeOne ...
+func (userRepo *UserMySQLRepository) PurgeOne(userID uint64) error {
+	return userRepo.PurgeOneContext(context.Background(), userID)
+}
+
+// PurgeOneContext hard deletes soft deleted user and its followership
+// within the same transaction, counters of users it follows and users
+// following it are decremented, including those of deleted users.
+func (userRepo *UserMySQLRepository) PurgeOneContext(ctx context.Context, userID uint64) error {
+	db := userRepo.dbContext(ctx)
+
+	err := repocommon.Transaction(db, func(tx *gorm.DB) error {
+		tx = tx.Unscoped()
+
+		// SELECT * FROM `users` WHERE (id = ? AND deleted_at IS NOT NULL) ...
+		if err := tx.Where("id = ? AND deleted_at IS NOT NULL", userID).First(&UserDB{}).Error; err != nil {
+			return err
+		}
+
+		counters := []struct {
+			column  string
+			related string
+			where   string
+		}{
+			{"followers_count", "followed_id", "follower_id = ?"},
+			{"following_count", "follower_id", "followed_id = ?"},
+		}
+		for _, counter := range counters {
+			// UPDATE `users` SET `column` = column - 1
+			// WHERE (id IN (SELECT related FROM `followership` WHERE (where)))
+			related := tx.Table("followership").Select(counter.related).Where(counter.where, userID).SubQuery()
+			err := tx.Model(&UserDB{}).Where("id IN ?", related).
+				UpdateColumn(counter.column, gorm.Expr(counter.column+" - ?", 1)).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// DELETE FROM `followership` WHERE (follower_id = ? OR followed_id = ?)
+		err := tx.Where("follower_id = ? OR followed_id = ?", userID, userID).Delete(&FollowershipDB{}).Error
+		if err != nil {
+			return err
+		}
+		// DELETE FROM `users` WHERE `users`.`id` = ?
+		return tx.Delete(&UserDB{ID: userID}).Error
+	})
+	return userRepo.appError(ctx, err, "userrepo: purge one user fail")
+}
+
+// RelateUsers makes follower follows the followed user. Counters of
+// both users are updated within the same transaction.
+func (userRepo *UserMySQLRepository) RelateUsers(followedID uint64, followerID uint64) error {
+	return userRepo.RelateUsersContext(context.Background(), followedID, followerID)
+}
+
+// RelateUsersContext is RelateUsers aborted by ctx cancellation or deadline
+func (userRepo *UserMySQLRepository) RelateUsersContext(ctx context.Context, followedID uint64, followerID uint64) error {
+	var (
+		followDB = &FollowershipDB{FollowerID: followerID, FollowedID: followedID}
+		db       = userRepo.dbContext(ctx)
+	)
+
+	err := repocommon.Transaction(db, func(tx *gorm.DB) error {
+		// INSERT INTO `followership` (`follower_id`,`followed_id`) VALUES (?,?)
+		if err := tx.Create(followDB).Error; err != nil {
+			return err
+		}
+		return updateFollowCounters(tx, followedID, followerID, 1)
+	})
+	return userRepo.appError(ctx, err, "userrepo: relate users fail")
+}
+
+// UnrelateUsers makes follower unfollows the followed user. Counters
+// of both users are updated within the same transaction.
+func (userRepo *UserMySQLRepository) UnrelateUsers(followedID uint64, followerID uint64) error {
+	return userRepo.UnrelateUsersContext(context.Background(), followedID, followerID)
+}
+
+// UnrelateUsersContext is UnrelateUsers aborted by ctx cancellation or deadline
+func (userRepo *UserMySQLRepository) UnrelateUsersContext(ctx context.Context, followedID uint64, followerID uint64) error {
+	db := userRepo.dbContext(ctx)
+
+	err := repocommon.Transaction(db, func(tx *gorm.DB) error {
+		// DELETE FROM `followership` WHERE (follower_id = ? AND followed_id = ?)
+		res := tx.Where("follower_id = ? AND followed_id = ?", followerID, followedID).
+			Delete(&FollowershipDB{})
+		if err := res.Error; err != nil {
+			return err
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return updateFollowCounters(tx, followedID, followerID, -1)
+	})
+	return userRepo.appError(ctx, err, "userrepo: unrelate users fail")
+}
+
+// updateFollowCounters adds delta to followers_count of followed user
+// and following_count of follower. tx MUST be a transaction.
+func updateFollowCounters(tx *gorm.DB, followedID uint64, followerID uint64, delta int) error {
+	counters := []struct {
+		column string
+		userID uint64
+	}{
+		{"followers_count", followedID},
+		{"following_count", followerID},
+	}
+
+	for _, counter := range counters {
+		// UPDATE `users` SET `column` = column + ? WHERE (id = ?)
+		res := tx.Model(&UserDB{}).Where("id = ?", counter.userID).
+			UpdateColumn(counter.column, gorm.Expr(counter.column+" + ?", delta))
+		if err := res.Error; err != nil {
+			return err
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	return nil
+}
+
+// ListFollowers returns paginated users that follow user with given id
+func (userRepo *UserMySQLRepository) ListFollowers(userID uint64, page int, limit int) ([]domain.User, error) {
+	return userRepo.ListFollowersContext(context.Background(), userID, page, limit)
+}
+
+// ListFollowersContext is ListFollowers aborted by ctx cancellation or deadline
+func (userRepo *UserMySQLRepository) ListFollowersContext(ctx context.Context, userID uint64, page int, limit int) ([]domain.User, error) {
+	return userRepo.listFollowership(ctx,
+		"followership.follower_id = users.id", "followership.followed_id = ?",
+		userID, page, limit)
+}
+
+// ListFollowing returns paginated users followed by user with given id
+func (userRepo *UserMySQLRepository) ListFollowing(userID uint64, page int, limit int) ([]domain.User, error) {
+	return userRepo.ListFollowingContext(context.Background(), userID, page, limit)
+}
+
+// ListFollowingContext is ListFollowing aborted by ctx cancellation or deadline
+func (userRepo *UserMySQLRepository) ListFollowingContext(ctx context.Context, userID uint64, page int, limit int) ([]domain.User, error) {
+	return userRepo.listFollowership(ctx,
+		"followership.followed_id = users.id", "followership.follower_id = ?",
+		userID, page, limit)
+}
+
+func (userRepo *UserMySQLRepository) listFollowership(ctx context.Context,
+	joinOn string, where string, userID uint64, page int, limit int) ([]domain.User, error) {
+	var (
+		usersDB = make([]UserDB, 0)
+		db      = userRepo.dbContext(ctx)
+	)
+	offset, limit := pagination(page, limit)
+
+	// SELECT `users`.* FROM `users` JOIN followership ON (joinOn)
+	// WHERE (where) ORDER BY users.id LIMIT (limit) OFFSET (offset)
+	err := db.Joins("JOIN followership ON "+joinOn).Where(where, userID).
+		Order("users.id").Offset(offset).Limit(limit).Find(&usersDB).Error
+	if err != nil {
+		return nil, userRepo.appError(ctx, err, "userrepo: list followership fail")
+	}
+
+	users := make([]domain.User, 0, len(usersDB))
+	for _, userDB := range usersDB {
+		users = append(users, userDB.User())
+	}
+	return users, nil
+}
+
+// rowsAffectedError returns db error, or gorm.ErrRecordNotFound
+// when the statement does not affect any row
+func rowsAffectedError(db *gorm.DB) error {
+	if db.Error == nil && db.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return db.Error
+}
+
+// pagination converts 1-indexed page and limit to row offset and limit.
+// Non-positive page defaults to 1, limit is bounded to DefaultLimit.
+func pagination(page int, limit int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > int(DefaultLimit) {
+		limit = int(DefaultLimit)
+	}
+	return (page - 1) * limit, limit
+}
+
+// escapeLike escapes LIKE wildcards so str is matched literally
+func escapeLike(str string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(str)
+}
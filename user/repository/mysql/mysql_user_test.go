@@ -2,6 +2,7 @@
 
 import (
 	// import built-in libraries
+	"context"
 	"database/sql/driver"
 	"log"
 	"os"
@@ -11,8 +12,11 @@
 
 	// import third-party libraries
 	"github.com/DATA-DOG/go-sqlmock"
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"github.com/go-test/deep"
 	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/lib/random"
+	repocommon "github.com/iqdf/golumn-story-service/lib/repository"
 	"github.com/jinzhu/gorm"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -79,7 +83,7 @@ func userToRows(user domain.User) []driver.Value {
 		user.Location, user.Description,
 		user.FollowersCount, user.FollowingCount,
 		user.TwitterName, user.FacebookName,
-		time.Now(), time.Now(),
+		user.CreatedAt, user.UpdatedAt, nil,
 	}
 }
 
@@ -93,7 +97,7 @@ func userToInsertArgs(user domain.User) []driver.Value {
 		user.Location, user.Description,
 		user.FollowersCount, user.FollowingCount,
 		user.TwitterName, user.FacebookName,
-		AnyTimeArg{}, AnyTimeArg{},
+		AnyTimeArg{}, AnyTimeArg{}, nil,
 	}
 }
 
@@ -105,13 +109,15 @@ func userToInsertArgs(user domain.User) []driver.Value {
 	ProfileImgURL: "google.profile.com/userzero",
 	Location:      "Singapore, Jurong",
 	Description:   "AboutMe...",
+	CreatedAt:     time.Date(2020, 4, 1, 8, 0, 0, 0, time.UTC),
+	UpdatedAt:     time.Date(2020, 4, 2, 8, 0, 0, 0, time.UTC),
 }
 
 func (tsuite *TestSuite) TestShouldGetByID() {
 	rows := sqlmock.NewRows(UserColumns()).
 		AddRow(userToRows(mockUser)...)
 
-	queryStr := regexp.QuoteMeta("SELECT * FROM `users` WHERE (id = ?) ORDER BY `users`.`id` ASC LIMIT 1")
+	queryStr := regexp.QuoteMeta("SELECT * FROM `users`  WHERE `users`.`deleted_at` IS NULL AND ((id = ?)) ORDER BY `users`.`id` ASC LIMIT 1")
 
 	tsuite.T().Log("\nDebug UserColumns:", UserColumns(), "\n")
 
@@ -128,11 +134,46 @@ func (tsuite *TestSuite) TestShouldGetByID() {
 	tsuite.Require().Nil(deep.Equal(getUser, mockUser))
 }
 
+func (tsuite *TestSuite) TestShouldTimeoutGetByIDContext() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	rows := sqlmock.NewRows(UserColumns()).
+		AddRow(userToRows(mockUser)...)
+	queryStr := regexp.QuoteMeta("SELECT * FROM `users`  WHERE `users`.`deleted_at` IS NULL AND ((id = ?)) ORDER BY `users`.`id` ASC LIMIT 1")
+
+	// register slow query which outlives ctx deadline
+	tsuite.Mock.ExpectQuery(queryStr).
+		WithArgs(mockUser.ID).
+		WillDelayFor(time.Second).
+		WillReturnRows(rows)
+
+	// run gorm tx - get user by id
+	_, err := tsuite.Repository.GetByIDContext(ctx, mockUser.ID)
+	tsuite.T().Log("\nDebug Error Log:", err, "\n")
+	tsuite.Require().Error(err)
+	tsuite.Require().Equal(domain.RequestTimeoutCode, err.(*domain.AppError).Code())
+	tsuite.Require().Equal(504, err.(*domain.AppError).HTTPCode())
+}
+
+func (tsuite *TestSuite) TestShouldNotBeginCancelledTransaction() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// run gorm tx - relate users: transaction
+	// is never started with cancelled ctx
+	err := tsuite.Repository.RelateUsersContext(ctx, 2, 1)
+	tsuite.T().Log("\nDebug Error Log:", err, "\n")
+	tsuite.Require().Error(err)
+	tsuite.Require().Equal(domain.RequestCancelledCode, err.(*domain.AppError).Code())
+	tsuite.Require().Equal(domain.StatusClientClosedRequest, err.(*domain.AppError).HTTPCode())
+}
+
 func (tsuite *TestSuite) TestShouldGetByEmail() {
 	rows := sqlmock.NewRows(UserColumns()).
 		AddRow(userToRows(mockUser)...)
 
-	queryStr := regexp.QuoteMeta("SELECT * FROM `users` WHERE (email = ?) ORDER BY `users`.`id` ASC LIMIT 1")
+	queryStr := regexp.QuoteMeta("SELECT * FROM `users`  WHERE `users`.`deleted_at` IS NULL AND ((email = ?)) ORDER BY `users`.`id` ASC LIMIT 1")
 
 	tsuite.T().Log("\nDebug UserColumns:", UserColumns(), "\n")
 
@@ -153,7 +194,7 @@ func (tsuite *TestSuite) TestShouldGetByUsername() {
 	rows := sqlmock.NewRows(UserColumns()).
 		AddRow(userToRows(mockUser)...)
 
-	queryStr := regexp.QuoteMeta("SELECT * FROM `users` WHERE (username = ?) ORDER BY `users`.`id` ASC LIMIT 1")
+	queryStr := regexp.QuoteMeta("SELECT * FROM `users`  WHERE `users`.`deleted_at` IS NULL AND ((username = ?)) ORDER BY `users`.`id` ASC LIMIT 1")
 
 	tsuite.T().Log("\nDebug UserColumns:", UserColumns(), "\n")
 
@@ -176,8 +217,8 @@ func (tsuite *TestSuite) TestShouldInsertOne() {
 		"INSERT INTO `users` " +
 			"(`email`,`username`,`name`,`profile_img_url`,`location`,`description`," +
 			"`followers_count`,`following_count`,`twitter_name`,`facebook_name`," +
-			"`created_at`,`updated_at`) " +
-			"VALUES (?,?,?,?,?,?,?,?,?,?,?,?)")
+			"`created_at`,`updated_at`,`deleted_at`) " +
+			"VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?)")
 
 	// register expected tx operations
 	// and define mocked db response
@@ -193,6 +234,116 @@ func (tsuite *TestSuite) TestShouldInsertOne() {
 	tsuite.Require().NoError(err)
 }
 
+func (tsuite *TestSuite) TestShouldInsertOneWithSnowflakeID() {
+	snowflake, err := random.NewSnowflakeGenerator(1)
+	tsuite.Require().NoError(err)
+	repository := NewUserMySQLRepository(tsuite.DB, snowflake)
+
+	execStr := regexp.QuoteMeta(
+		"INSERT INTO `users` " +
+			"(`id`,`email`,`username`,`name`,`profile_img_url`,`location`,`description`," +
+			"`followers_count`,`following_count`,`twitter_name`,`facebook_name`," +
+			"`created_at`,`updated_at`,`deleted_at`) " +
+			"VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?)")
+	args := append([]driver.Value{sqlmock.AnyArg()}, userToInsertArgs(mockUser)...)
+
+	// register expected tx operations
+	// and define mocked db response
+	for i := 0; i < 2; i++ {
+		tsuite.Mock.ExpectBegin()
+		tsuite.Mock.ExpectExec(execStr).
+			WithArgs(args...).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		tsuite.Mock.ExpectCommit()
+	}
+
+	// run gorm tx: insert mock user twice,
+	// ids are time ordered within the node
+	first, err := repository.InsertOne(mockUser)
+	tsuite.Require().NoError(err)
+	second, err := repository.InsertOne(mockUser)
+	tsuite.Require().NoError(err)
+
+	tsuite.Require().True(second.ID > first.ID)
+	tsuite.Require().WithinDuration(time.Now(), random.SnowflakeTime(second.ID), time.Second)
+}
+
+// scriptedIDs generates ids in given order
+type scriptedIDs struct{ ids []uint64 }
+
+func (gen *scriptedIDs) Uint32() uint32 { return uint32(gen.Uint64()) }
+
+func (gen *scriptedIDs) Uint64() uint64 {
+	id := gen.ids[0]
+	gen.ids = gen.ids[1:]
+	return id
+}
+
+func (tsuite *TestSuite) TestShouldRetryInsertOneOnIDCollision() {
+	repository := NewUserMySQLRepository(tsuite.DB, &scriptedIDs{ids: []uint64{7, 8}})
+	execStr := regexp.QuoteMeta("INSERT INTO `users` (`id`,`email`,")
+	errCollision := &mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry '7' for key 'PRIMARY'"}
+
+	// register expected tx operations: first id
+	// collides, second id is inserted
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(execStr).
+		WithArgs(append([]driver.Value{7}, userToInsertArgs(mockUser)...)...).
+		WillReturnError(errCollision)
+	tsuite.Mock.ExpectRollback()
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(execStr).
+		WithArgs(append([]driver.Value{8}, userToInsertArgs(mockUser)...)...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: insert mock user
+	user, err := repository.InsertOne(mockUser)
+	tsuite.T().Log("\nDebug Error Log:", err, "\n")
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(uint64(8), user.ID)
+}
+
+func (tsuite *TestSuite) TestShouldNotRetryInsertOneOnDuplicateUsername() {
+	repository := NewUserMySQLRepository(tsuite.DB, &scriptedIDs{ids: []uint64{7, 8}})
+	execStr := regexp.QuoteMeta("INSERT INTO `users` (`id`,`email`,")
+	errDuplicate := &mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'UserZero' for key 'uix_users_username'"}
+
+	// register expected tx operations: username
+	// duplicate is client fault, never retried
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(execStr).
+		WithArgs(append([]driver.Value{7}, userToInsertArgs(mockUser)...)...).
+		WillReturnError(errDuplicate)
+	tsuite.Mock.ExpectRollback()
+
+	// run gorm tx: insert mock user
+	_, err := repository.InsertOne(mockUser)
+	tsuite.T().Log("\nDebug Error Log:", err, "\n")
+	tsuite.Require().Error(err)
+	tsuite.Require().Equal(domain.InvalidParamCode, err.(*domain.AppError).Code())
+	tsuite.Require().Contains(err.Error(), "conflict duplicate UserZero")
+}
+
+func (tsuite *TestSuite) TestShouldGiveUpInsertOneOnIDCollision() {
+	repository := NewUserMySQLRepository(tsuite.DB, &scriptedIDs{ids: []uint64{7, 7, 7}})
+	execStr := regexp.QuoteMeta("INSERT INTO `users` (`id`,`email`,")
+	errCollision := &mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry '7' for key 'users.PRIMARY'"}
+
+	// register expected tx operations: every attempt collides
+	for i := 0; i < MaxInsertAttempts; i++ {
+		tsuite.Mock.ExpectBegin()
+		tsuite.Mock.ExpectExec(execStr).WillReturnError(errCollision)
+		tsuite.Mock.ExpectRollback()
+	}
+
+	// run gorm tx: insert mock user
+	_, err := repository.InsertOne(mockUser)
+	tsuite.T().Log("\nDebug Error Log:", err, "\n")
+	tsuite.Require().Error(err)
+	tsuite.Require().Equal(domain.InternalErrorCode, err.(*domain.AppError).Code())
+}
+
 func (tsuite *TestSuite) TestShouldUpdateOne() {
 	mockUser := domain.User{
 		ID:            1,
@@ -208,7 +359,7 @@ func (tsuite *TestSuite) TestShouldUpdateOne() {
 	// see NewUserDBUpdater for update-only fields
 	execStr := regexp.QuoteMeta("UPDATE `users` SET " +
 		"`description` = ?, `location` = ?, `name` = ?, `profile_img_url` = ?, `updated_at` = ? " +
-		"WHERE `users`.`id` = ?")
+		"WHERE `users`.`deleted_at` IS NULL AND `users`.`id` = ?")
 
 	args := []driver.Value{
 		mockUser.Description, mockUser.Location,
@@ -233,13 +384,14 @@ func (tsuite *TestSuite) TestShouldUpdateOne() {
 func (tsuite *TestSuite) TestShouldDeleteOne() {
 	var userID uint64 = 1
 	deleteResult := sqlmock.NewResult(1, 1)
-	execStr := regexp.QuoteMeta("DELETE FROM `users` WHERE `users`.`id` = ?")
+	execStr := regexp.QuoteMeta("UPDATE `users` SET `deleted_at`=? " +
+		"WHERE `users`.`deleted_at` IS NULL AND `users`.`id` = ?")
 
-	// register expected tx operation
+	// register expected tx operation, user is soft deleted
 	// and defined mocked db response
 	tsuite.Mock.ExpectBegin()
 	tsuite.Mock.ExpectExec(execStr).
-		WithArgs(userID).
+		WithArgs(AnyTimeArg{}, userID).
 		WillReturnResult(deleteResult)
 	tsuite.Mock.ExpectCommit()
 
@@ -248,3 +400,372 @@ func (tsuite *TestSuite) TestShouldDeleteOne() {
 	tsuite.T().Log("\nDebug Error Log:", err, "\n")
 	tsuite.Require().NoError(err)
 }
+
+func (tsuite *TestSuite) TestShouldNotDeleteUnknownUser() {
+	var userID uint64 = 404
+	execStr := regexp.QuoteMeta("UPDATE `users` SET `deleted_at`=? " +
+		"WHERE `users`.`deleted_at` IS NULL AND `users`.`id` = ?")
+
+	// register expected tx operation: no row deleted
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(execStr).
+		WithArgs(AnyTimeArg{}, userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: delete unknown user
+	err := tsuite.Repository.DeleteOne(userID)
+	tsuite.T().Log("\nDebug Error Log:", err, "\n")
+	tsuite.Require().Error(err)
+	tsuite.Require().Equal(domain.UnknownResourceCode, err.(*domain.AppError).Code())
+}
+
+func (tsuite *TestSuite) TestShouldNotUpdateUnknownUser() {
+	var userID uint64 = 404
+	execStr := regexp.QuoteMeta("UPDATE `users` SET `name` = ?, `updated_at` = ? WHERE `users`.`deleted_at` IS NULL AND `users`.`id` = ?")
+
+	// register expected tx operation: no row updated
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(execStr).
+		WithArgs("Hoi Hoi", AnyTimeArg{}, userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: update unknown user
+	_, err := tsuite.Repository.UpdateOne(userID, domain.User{Name: "Hoi Hoi"})
+	tsuite.T().Log("\nDebug Error Log:", err, "\n")
+	tsuite.Require().Error(err)
+	tsuite.Require().Equal(domain.UnknownResourceCode, err.(*domain.AppError).Code())
+}
+
+func (tsuite *TestSuite) TestShouldRelateUsers() {
+	var followedID, followerID uint64 = 2, 1
+	insertStr := regexp.QuoteMeta("INSERT INTO `followership` (`follower_id`,`followed_id`) VALUES (?,?)")
+	followersStr := regexp.QuoteMeta("UPDATE `users` SET `followers_count` = followers_count + ? WHERE `users`.`deleted_at` IS NULL AND ((id = ?))")
+	followingStr := regexp.QuoteMeta("UPDATE `users` SET `following_count` = following_count + ? WHERE `users`.`deleted_at` IS NULL AND ((id = ?))")
+
+	// register expected tx operations: relationship and
+	// both counters are written within one transaction
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(insertStr).
+		WithArgs(followerID, followedID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectExec(followersStr).
+		WithArgs(1, followedID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectExec(followingStr).
+		WithArgs(1, followerID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: relate users
+	err := tsuite.Repository.RelateUsers(followedID, followerID)
+	tsuite.T().Log("\nDebug Error Log:", err, "\n")
+	tsuite.Require().NoError(err)
+}
+
+func (tsuite *TestSuite) TestShouldRelateUsersInUnitOfWork() {
+	var followedID, followerID uint64 = 2, 1
+	insertStr := regexp.QuoteMeta("INSERT INTO `followership` (`follower_id`,`followed_id`) VALUES (?,?)")
+	followersStr := regexp.QuoteMeta("UPDATE `users` SET `followers_count` = followers_count + ? WHERE `users`.`deleted_at` IS NULL AND ((id = ?))")
+	followingStr := regexp.QuoteMeta("UPDATE `users` SET `following_count` = following_count + ? WHERE `users`.`deleted_at` IS NULL AND ((id = ?))")
+
+	uow := repocommon.NewGormUnitOfWork(tsuite.DB, func(tx *gorm.DB) domain.Repositories {
+		return domain.Repositories{Users: NewUserMySQLRepository(tx, NewIDMocker())}
+	}, repocommon.NewMySQLErrCvt())
+
+	// register expected tx operations: relate users
+	// joins the unit of work instead of nested transaction
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(insertStr).
+		WithArgs(followerID, followedID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectExec(followersStr).
+		WithArgs(1, followedID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectExec(followingStr).
+		WithArgs(1, followerID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: relate users within unit of work
+	err := uow.Do(context.Background(), func(repos domain.Repositories) error {
+		return repos.Users.RelateUsersContext(context.Background(), followedID, followerID)
+	})
+	tsuite.T().Log("\nDebug Error Log:", err, "\n")
+	tsuite.Require().NoError(err)
+}
+
+func (tsuite *TestSuite) TestShouldRollbackRelateUnknownUsers() {
+	var followedID, followerID uint64 = 2, 1
+	insertStr := regexp.QuoteMeta("INSERT INTO `followership` (`follower_id`,`followed_id`) VALUES (?,?)")
+	followersStr := regexp.QuoteMeta("UPDATE `users` SET `followers_count` = followers_count + ? WHERE `users`.`deleted_at` IS NULL AND ((id = ?))")
+
+	// register expected tx operations: counter update
+	// of unknown user must rollback the relationship
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(insertStr).
+		WithArgs(followerID, followedID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectExec(followersStr).
+		WithArgs(1, followedID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	tsuite.Mock.ExpectRollback()
+
+	// run gorm tx: relate users
+	err := tsuite.Repository.RelateUsers(followedID, followerID)
+	tsuite.T().Log("\nDebug Error Log:", err, "\n")
+	tsuite.Require().Error(err)
+	tsuite.Require().Equal(domain.UnknownResourceCode, err.(*domain.AppError).Code())
+}
+
+func (tsuite *TestSuite) TestShouldUnrelateUsers() {
+	var followedID, followerID uint64 = 2, 1
+	deleteStr := regexp.QuoteMeta("DELETE FROM `followership` WHERE (follower_id = ? AND followed_id = ?)")
+	followersStr := regexp.QuoteMeta("UPDATE `users` SET `followers_count` = followers_count + ? WHERE `users`.`deleted_at` IS NULL AND ((id = ?))")
+	followingStr := regexp.QuoteMeta("UPDATE `users` SET `following_count` = following_count + ? WHERE `users`.`deleted_at` IS NULL AND ((id = ?))")
+
+	// register expected tx operations
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(deleteStr).
+		WithArgs(followerID, followedID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectExec(followersStr).
+		WithArgs(-1, followedID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectExec(followingStr).
+		WithArgs(-1, followerID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: unrelate users
+	err := tsuite.Repository.UnrelateUsers(followedID, followerID)
+	tsuite.T().Log("\nDebug Error Log:", err, "\n")
+	tsuite.Require().NoError(err)
+}
+
+func (tsuite *TestSuite) TestShouldNotUnrelateUnrelatedUsers() {
+	var followedID, followerID uint64 = 2, 1
+	deleteStr := regexp.QuoteMeta("DELETE FROM `followership` WHERE (follower_id = ? AND followed_id = ?)")
+
+	// register expected tx operations: nothing to
+	// unrelate, counters must be left untouched
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(deleteStr).
+		WithArgs(followerID, followedID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	tsuite.Mock.ExpectRollback()
+
+	// run gorm tx: unrelate users
+	err := tsuite.Repository.UnrelateUsers(followedID, followerID)
+	tsuite.T().Log("\nDebug Error Log:", err, "\n")
+	tsuite.Require().Error(err)
+	tsuite.Require().Equal(domain.UnknownResourceCode, err.(*domain.AppError).Code())
+}
+
+func (tsuite *TestSuite) TestShouldListFollowers() {
+	rows := sqlmock.NewRows(UserColumns()).
+		AddRow(userToRows(mockUser)...)
+
+	queryStr := regexp.QuoteMeta("SELECT `users`.* FROM `users` " +
+		"JOIN followership ON followership.follower_id = users.id " +
+		"WHERE `users`.`deleted_at` IS NULL AND ((followership.followed_id = ?)) ORDER BY `users`.`id` LIMIT 10 OFFSET 10")
+
+	// register expected query and mocked rows
+	tsuite.Mock.ExpectQuery(queryStr).
+		WithArgs(2).
+		WillReturnRows(rows)
+
+	// run gorm query: second page of followers
+	users, err := tsuite.Repository.ListFollowers(2, 2, 10)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(users, 1)
+	tsuite.Require().Nil(deep.Equal(users[0], mockUser))
+}
+
+func (tsuite *TestSuite) TestShouldListFollowingWithDefaultLimit() {
+	rows := sqlmock.NewRows(UserColumns())
+
+	queryStr := regexp.QuoteMeta("SELECT `users`.* FROM `users` " +
+		"JOIN followership ON followership.followed_id = users.id " +
+		"WHERE `users`.`deleted_at` IS NULL AND ((followership.follower_id = ?)) ORDER BY `users`.`id` LIMIT 20 OFFSET 0")
+
+	// register expected query and mocked rows
+	tsuite.Mock.ExpectQuery(queryStr).
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	// run gorm query: first page of following
+	users, err := tsuite.Repository.ListFollowing(1, 0, 0)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Empty(users)
+}
+
+func (tsuite *TestSuite) TestShouldFetchMany() {
+	filter := domain.User{Name: "Name_", Location: mockUser.Location}
+	countRows := sqlmock.NewRows([]string{"count(*)"}).AddRow(3)
+	rows := sqlmock.NewRows(UserColumns()).
+		AddRow(userToRows(mockUser)...).
+		AddRow(userToRows(mockUser)...)
+
+	countStr := regexp.QuoteMeta("SELECT count(*) FROM `users`  " +
+		"WHERE `users`.`deleted_at` IS NULL AND ((name LIKE ?) AND (location = ?))")
+	queryStr := regexp.QuoteMeta("SELECT * FROM `users`  " +
+		"WHERE `users`.`deleted_at` IS NULL AND ((name LIKE ?) AND (location = ?)) ORDER BY `id` LIMIT 2 OFFSET 0")
+
+	// register expected count then select queries, name prefix
+	// wildcards are escaped to be matched literally
+	tsuite.Mock.ExpectQuery(countStr).
+		WithArgs(`Name\_%`, mockUser.Location).
+		WillReturnRows(countRows)
+	tsuite.Mock.ExpectQuery(queryStr).
+		WithArgs(`Name\_%`, mockUser.Location).
+		WillReturnRows(rows)
+
+	// run gorm query: first page of users
+	users, meta, err := tsuite.Repository.FetchMany(filter, 1, 2)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(users, 2)
+	tsuite.Require().Equal(domain.Metadata{Total: 3, Page: 1, Limit: 2, NextCursor: "2"}, meta)
+}
+
+func (tsuite *TestSuite) TestShouldFetchManyLastPage() {
+	countRows := sqlmock.NewRows([]string{"count(*)"}).AddRow(21)
+	rows := sqlmock.NewRows(UserColumns()).
+		AddRow(userToRows(mockUser)...)
+
+	countStr := regexp.QuoteMeta("SELECT count(*) FROM `users`  WHERE `users`.`deleted_at` IS NULL")
+	queryStr := regexp.QuoteMeta("SELECT * FROM `users`  WHERE `users`.`deleted_at` IS NULL ORDER BY `id` LIMIT 20 OFFSET 20")
+
+	// register expected queries, limit above
+	// DefaultLimit is bounded to DefaultLimit
+	tsuite.Mock.ExpectQuery(countStr).WillReturnRows(countRows)
+	tsuite.Mock.ExpectQuery(queryStr).WillReturnRows(rows)
+
+	// run gorm query: second page of all users
+	users, meta, err := tsuite.Repository.FetchMany(domain.User{}, 2, 100)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(users, 1)
+	tsuite.Require().Equal(domain.Metadata{Total: 21, Page: 2, Limit: 20}, meta)
+}
+
+func (tsuite *TestSuite) TestShouldRestoreOne() {
+	deletedAfter := time.Now().Add(-time.Hour)
+	rows := sqlmock.NewRows(UserColumns()).
+		AddRow(userToRows(mockUser)...)
+
+	execStr := regexp.QuoteMeta("UPDATE `users` SET `deleted_at` = ? WHERE (id = ? AND deleted_at > ?)")
+	queryStr := regexp.QuoteMeta("SELECT * FROM `users`  WHERE `users`.`deleted_at` IS NULL AND ((id = ?)) " +
+		"ORDER BY `users`.`id` ASC LIMIT 1")
+
+	// register expected tx operations: user deleted within
+	// grace period is restored then read in one transaction
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(execStr).
+		WithArgs(nil, mockUser.ID, deletedAfter).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectQuery(queryStr).
+		WithArgs(mockUser.ID).
+		WillReturnRows(rows)
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: restore mock user
+	user, err := tsuite.Repository.RestoreOne(mockUser.ID, deletedAfter)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Nil(deep.Equal(user, mockUser))
+}
+
+func (tsuite *TestSuite) TestShouldNotRestoreExpiredUser() {
+	deletedAfter := time.Now().Add(-time.Hour)
+	execStr := regexp.QuoteMeta("UPDATE `users` SET `deleted_at` = ? WHERE (id = ? AND deleted_at > ?)")
+
+	// register expected tx operations: user deleted
+	// before grace period is not restored
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(execStr).
+		WithArgs(nil, mockUser.ID, deletedAfter).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	tsuite.Mock.ExpectRollback()
+
+	// run gorm tx: restore expired mock user
+	_, err := tsuite.Repository.RestoreOne(mockUser.ID, deletedAfter)
+	tsuite.Require().Error(err)
+	tsuite.Require().Equal(domain.UnknownResourceCode, err.(*domain.AppError).Code())
+}
+
+func (tsuite *TestSuite) TestShouldListDeleted() {
+	deletedBefore := time.Now().Add(-time.Hour)
+	rows := sqlmock.NewRows(UserColumns()).
+		AddRow(userToRows(mockUser)...)
+
+	queryStr := regexp.QuoteMeta("SELECT * FROM `users` " +
+		"WHERE (deleted_at IS NOT NULL AND deleted_at <= ?) ORDER BY deleted_at,`id` LIMIT 100")
+
+	// register expected query, limit above DefaultLimit is kept,
+	// as purger lists past users it failed to purge
+	tsuite.Mock.ExpectQuery(queryStr).
+		WithArgs(deletedBefore).
+		WillReturnRows(rows)
+
+	// run gorm query: deleted users
+	users, err := tsuite.Repository.ListDeleted(deletedBefore, 100)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(users, 1)
+}
+
+func (tsuite *TestSuite) TestShouldPurgeOne() {
+	var userID uint64 = 1
+	rows := sqlmock.NewRows(UserColumns()).
+		AddRow(userToRows(mockUser)...)
+
+	queryStr := regexp.QuoteMeta("SELECT * FROM `users` WHERE (id = ? AND deleted_at IS NOT NULL) " +
+		"ORDER BY `users`.`id` ASC LIMIT 1")
+	followersStr := regexp.QuoteMeta("UPDATE `users` SET `followers_count` = followers_count - ? " +
+		"WHERE (id IN (SELECT followed_id FROM `followership`  WHERE (follower_id = ?)))")
+	followingStr := regexp.QuoteMeta("UPDATE `users` SET `following_count` = following_count - ? " +
+		"WHERE (id IN (SELECT follower_id FROM `followership`  WHERE (followed_id = ?)))")
+	followershipStr := regexp.QuoteMeta("DELETE FROM `followership`  WHERE (follower_id = ? OR followed_id = ?)")
+	deleteStr := regexp.QuoteMeta("DELETE FROM `users`  WHERE `users`.`id` = ?")
+
+	// register expected tx operations: counters of related
+	// users, followership and user are written in one transaction
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectQuery(queryStr).
+		WithArgs(userID).
+		WillReturnRows(rows)
+	tsuite.Mock.ExpectExec(followersStr).
+		WithArgs(1, userID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	tsuite.Mock.ExpectExec(followingStr).
+		WithArgs(1, userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectExec(followershipStr).
+		WithArgs(userID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	tsuite.Mock.ExpectExec(deleteStr).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: purge mock user
+	err := tsuite.Repository.PurgeOne(userID)
+	tsuite.Require().NoError(err)
+}
+
+func (tsuite *TestSuite) TestShouldNotPurgeActiveUser() {
+	queryStr := regexp.QuoteMeta("SELECT * FROM `users` WHERE (id = ? AND deleted_at IS NOT NULL) " +
+		"ORDER BY `users`.`id` ASC LIMIT 1")
+
+	// register expected tx operations: user
+	// not soft deleted is not purged
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectQuery(queryStr).
+		WithArgs(mockUser.ID).
+		WillReturnRows(sqlmock.NewRows(UserColumns()))
+	tsuite.Mock.ExpectRollback()
+
+	// run gorm tx: purge active mock user
+	err := tsuite.Repository.PurgeOne(mockUser.ID)
+	tsuite.Require().Error(err)
+	tsuite.Require().Equal(domain.UnknownResourceCode, err.(*domain.AppError).Code())
+}
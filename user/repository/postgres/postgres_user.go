@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	// import third-party libraries
+	"github.com/jinzhu/gorm"
+
+	// import our local packages
+	repocommon "github.com/iqdf/golumn-story-service/lib/repository"
+	usermysql "github.com/iqdf/golumn-story-service/user/repository/mysql"
+)
+
+// UserPostgresRepository implements domain.UserRepository
+// backed by PostgreSQL. It shares the gorm queries and models
+// of usermysql.UserMySQLRepository, as gorm builds the SQL
+// of the dialect db is opened with, and only differs in
+// converting PostgreSQL errors to domain.AppError.
+type UserPostgresRepository struct {
+	*usermysql.UserMySQLRepository
+}
+
+// NewUserPostgresRepository creates new UserPostgresRepository
+// from db opened with gorm "postgres" dialect
+func NewUserPostgresRepository(db *gorm.DB, rand usermysql.UIntRandomizer) *UserPostgresRepository {
+	return &UserPostgresRepository{
+		UserMySQLRepository: &usermysql.UserMySQLRepository{
+			DB:     db,
+			Rand:   rand,
+			ErrCvt: repocommon.NewPostgresErrCvt(),
+		},
+	}
+}
@@ -0,0 +1,236 @@
+package postgres
+
+import (
+	// import built-in libraries
+	"database/sql/driver"
+	"log"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	// import third-party libraries
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-test/deep"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+	usermysql "github.com/iqdf/golumn-story-service/user/repository/mysql"
+)
+
+// AnyTimeArg matches sql Args of type time.Time
+// without caring the time value
+type AnyTimeArg struct{}
+
+func (a AnyTimeArg) Match(v driver.Value) bool {
+	_, ok := v.(time.Time)
+	return ok
+}
+
+// UserIDMocker mocks userID generation in UserRepo
+// such that it always generates the same id
+type UserIDMocker struct{}
+
+func NewIDMocker() *UserIDMocker { return &UserIDMocker{} }
+
+func (mockID *UserIDMocker) Uint32() uint32 { return 1 }
+
+func (mockID *UserIDMocker) Uint64() uint64 { return 1 }
+
+type TestSuite struct {
+	suite.Suite
+	DB         *gorm.DB
+	Mock       sqlmock.Sqlmock
+	Repository *UserPostgresRepository
+}
+
+func (tsuite *TestSuite) SetupSuite() {
+	db, mock, err := sqlmock.New()
+	require.NoError(tsuite.T(), err)
+
+	tsuite.DB, err = gorm.Open("postgres", db)
+	require.NoError(tsuite.T(), err)
+
+	tsuite.DB.SetLogger(log.New(os.Stdout, "\r\n", 0))
+	tsuite.DB.LogMode(true)
+
+	tsuite.Mock = mock
+
+	userIDMocker := NewIDMocker()
+	tsuite.Repository = NewUserPostgresRepository(tsuite.DB, userIDMocker)
+}
+
+func (tsuite *TestSuite) AfterTest(_, _ string) {
+	require.NoError(tsuite.T(), tsuite.Mock.ExpectationsWereMet())
+}
+
+func TestInit(t *testing.T) {
+	suite.Run(t, new(TestSuite))
+}
+
+// Warning! Columns order is important!
+// MUST Modify this func if UserDB model changes!
+// See usermysql.UserColumns() for list of columns.
+func userToRows(user domain.User) []driver.Value {
+	return []driver.Value{
+		user.ID, user.Email, user.Username,
+		user.Name, user.ProfileImgURL,
+		user.Location, user.Description,
+		user.FollowersCount, user.FollowingCount,
+		user.TwitterName, user.FacebookName,
+		user.CreatedAt, user.UpdatedAt, nil,
+	}
+}
+
+// Warning! Columns order is important!
+// MUST Modify this func if UserDB model changes!
+// See usermysql.NewUserDBWriter() for columns that will be inserted.
+func userToInsertArgs(user domain.User) []driver.Value {
+	return []driver.Value{
+		user.ID, user.Email, user.Username,
+		user.Name, user.ProfileImgURL,
+		user.Location, user.Description,
+		user.FollowersCount, user.FollowingCount,
+		user.TwitterName, user.FacebookName,
+		AnyTimeArg{}, AnyTimeArg{}, nil,
+	}
+}
+
+const insertStr = `INSERT INTO "users" ` +
+	`("id","email","username","name","profile_img_url","location","description",` +
+	`"followers_count","following_count","twitter_name","facebook_name",` +
+	`"created_at","updated_at","deleted_at") ` +
+	`VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14) RETURNING "users"."id"`
+
+var mockUser = domain.User{
+	ID:            1,
+	Email:         "UserZero-email@example.com",
+	Username:      "UserZero",
+	Name:          "Name-UserZero",
+	ProfileImgURL: "google.profile.com/userzero",
+	Location:      "Singapore, Jurong",
+	Description:   "AboutMe...",
+	CreatedAt:     time.Date(2020, 4, 1, 8, 0, 0, 0, time.UTC),
+	UpdatedAt:     time.Date(2020, 4, 2, 8, 0, 0, 0, time.UTC),
+}
+
+func (tsuite *TestSuite) TestShouldGetByUsername() {
+	rows := sqlmock.NewRows(usermysql.UserColumns()).
+		AddRow(userToRows(mockUser)...)
+
+	queryStr := regexp.QuoteMeta(`SELECT * FROM "users"  WHERE "users"."deleted_at" IS NULL AND ((username = $1)) ORDER BY "users"."id" ASC LIMIT 1`)
+
+	// register sequence of expected operations
+	// and defined returned rows to be mocked
+	tsuite.Mock.ExpectQuery(queryStr).
+		WithArgs(mockUser.Username).
+		WillReturnRows(rows)
+
+	// run gorm tx - get user by username
+	getUser, err := tsuite.Repository.GetByUsername(mockUser.Username)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Nil(deep.Equal(getUser, mockUser))
+}
+
+func (tsuite *TestSuite) TestShouldInsertOne() {
+	// register expected tx operations, postgres
+	// returns inserted primary key instead of exec result
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectQuery(regexp.QuoteMeta(insertStr)).
+		WithArgs(userToInsertArgs(mockUser)...).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(mockUser.ID))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: insert mock user
+	user, err := tsuite.Repository.InsertOne(mockUser)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(mockUser.ID, user.ID)
+}
+
+func (tsuite *TestSuite) TestShouldNotInsertDuplicateEmail() {
+	pqErr := &pq.Error{
+		Code:       "23505",
+		Message:    `duplicate key value violates unique constraint "uix_users_email"`,
+		Detail:     "Key (email)=(UserZero-email@example.com) already exists.",
+		Constraint: "uix_users_email",
+	}
+
+	// register expected tx operations failing on unique index
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectQuery(regexp.QuoteMeta(insertStr)).
+		WithArgs(userToInsertArgs(mockUser)...).
+		WillReturnError(pqErr)
+	tsuite.Mock.ExpectRollback()
+
+	// run gorm tx: insert mock user
+	_, err := tsuite.Repository.InsertOne(mockUser)
+	tsuite.Require().Error(err)
+
+	appErr, ok := err.(*domain.AppError)
+	tsuite.Require().True(ok)
+	tsuite.Require().Equal(domain.InvalidParamCode, appErr.Code())
+	tsuite.Require().Contains(appErr.Message(), "conflict duplicate email")
+}
+
+func (tsuite *TestSuite) TestShouldRetryInsertOneOnIDCollision() {
+	pqErr := &pq.Error{
+		Code:       "23505",
+		Message:    `duplicate key value violates unique constraint "users_pkey"`,
+		Detail:     "Key (id)=(1) already exists.",
+		Constraint: "users_pkey",
+	}
+
+	// register expected tx operations: generated id collides
+	// once, then the insert is retried with regenerated id
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectQuery(regexp.QuoteMeta(insertStr)).
+		WithArgs(userToInsertArgs(mockUser)...).
+		WillReturnError(pqErr)
+	tsuite.Mock.ExpectRollback()
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectQuery(regexp.QuoteMeta(insertStr)).
+		WithArgs(userToInsertArgs(mockUser)...).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(mockUser.ID))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: insert mock user
+	user, err := tsuite.Repository.InsertOne(mockUser)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(mockUser.ID, user.ID)
+}
+
+func (tsuite *TestSuite) TestShouldRetryInsertOneInTransaction() {
+	pqErr := &pq.Error{
+		Code:       "23505",
+		Message:    `duplicate key value violates unique constraint "users_pkey"`,
+		Detail:     "Key (id)=(1) already exists.",
+		Constraint: "users_pkey",
+	}
+
+	// register expected tx operations: collision aborts the transaction
+	// up to the savepoint only, so the retry runs in the same transaction
+	tsuite.Mock.ExpectBegin()
+	tsuite.Mock.ExpectExec(regexp.QuoteMeta("SAVEPOINT insert_user")).WillReturnResult(sqlmock.NewResult(0, 0))
+	tsuite.Mock.ExpectQuery(regexp.QuoteMeta(insertStr)).
+		WithArgs(userToInsertArgs(mockUser)...).
+		WillReturnError(pqErr)
+	tsuite.Mock.ExpectExec(regexp.QuoteMeta("ROLLBACK TO SAVEPOINT insert_user")).WillReturnResult(sqlmock.NewResult(0, 0))
+	tsuite.Mock.ExpectExec(regexp.QuoteMeta("SAVEPOINT insert_user")).WillReturnResult(sqlmock.NewResult(0, 0))
+	tsuite.Mock.ExpectQuery(regexp.QuoteMeta(insertStr)).
+		WithArgs(userToInsertArgs(mockUser)...).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(mockUser.ID))
+	tsuite.Mock.ExpectExec(regexp.QuoteMeta("RELEASE SAVEPOINT insert_user")).WillReturnResult(sqlmock.NewResult(0, 0))
+	tsuite.Mock.ExpectCommit()
+
+	// run gorm tx: insert mock user within ongoing transaction
+	err := tsuite.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := NewUserPostgresRepository(tx, NewIDMocker()).InsertOne(mockUser)
+		return err
+	})
+	tsuite.Require().NoError(err)
+}
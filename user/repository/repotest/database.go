@@ -0,0 +1,52 @@
+package repotest
+
+import (
+	// import built-in libraries
+	"os"
+	"testing"
+
+	// import third-party libraries
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/lib/migrations"
+	"github.com/iqdf/golumn-story-service/lib/random"
+)
+
+// DatabaseFactory creates repository of db, whose ids are
+// generated by ids, the generator of the production server
+type DatabaseFactory func(db *gorm.DB, ids *random.SnowflakeGenerator) domain.UserRepository
+
+// userTables are emptied before each test, children first
+var userTables = []string{"followership", "users"}
+
+// RunDatabaseSuite runs conformance test suite against a real database
+// of gorm dialect whose dsn is given by dsnEnv env, the test is skipped
+// if it is not set. The database is migrated up, and its user tables
+// are emptied before each test!
+func RunDatabaseSuite(t *testing.T, dialect string, dsnEnv string, factory DatabaseFactory) {
+	dsn := os.Getenv(dsnEnv)
+	if len(dsn) == 0 {
+		t.Skipf("%s is not set, skip %s conformance test", dsnEnv, dialect)
+	}
+
+	db, err := gorm.Open(dialect, dsn)
+	require.NoError(t, err)
+	defer db.Close()
+	migrator, err := migrations.NewMigrator(db)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	ids, err := random.NewSnowflakeGenerator(0)
+	require.NoError(t, err)
+
+	RunUserRepositorySuite(t, func(t *testing.T) domain.UserRepository {
+		for _, table := range userTables {
+			require.NoError(t, db.Exec("DELETE FROM "+table).Error)
+		}
+		return factory(db, ids)
+	})
+}
@@ -0,0 +1,478 @@
+// Package repotest provides conformance test suite of
+// domain.UserRepository, such that every implementation
+// behaves the same as the MySQL one. Backends run the suite
+// from their own test with a factory of empty repository,
+// or RunDatabaseSuite for repositories of a real database:
+//
+//	func TestConformance(t *testing.T) {
+//		repotest.RunUserRepositorySuite(t, func(t *testing.T) domain.UserRepository {
+//			return NewMyUserRepository(...)
+//		})
+//	}
+package repotest
+
+import (
+	// import built-in libraries
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	// import third-party libraries
+	"github.com/stretchr/testify/suite"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+)
+
+// Factory creates new, empty domain.UserRepository for each test
+type Factory func(t *testing.T) domain.UserRepository
+
+// RunUserRepositorySuite runs conformance test suite
+// against repositories created by factory
+func RunUserRepositorySuite(t *testing.T, factory Factory) {
+	suite.Run(t, &UserRepositorySuite{factory: factory})
+}
+
+// UserRepositorySuite is conformance test suite of domain.UserRepository
+type UserRepositorySuite struct {
+	suite.Suite
+	factory    Factory
+	Repository domain.UserRepository
+}
+
+// SetupTest creates new repository for each test
+func (tsuite *UserRepositorySuite) SetupTest() {
+	tsuite.Repository = tsuite.factory(tsuite.T())
+}
+
+// requireAppError asserts err is an *domain.AppError
+// with the same code and http code as expected error
+func (tsuite *UserRepositorySuite) requireAppError(err error, expected *domain.AppError) {
+	tsuite.Require().Error(err)
+	appErr, ok := err.(*domain.AppError)
+	tsuite.Require().True(ok, "expect *domain.AppError, got %T: %v", err, err)
+	tsuite.Require().Equal(expected.Code(), appErr.Code(), "unexpected error: %v", err)
+	tsuite.Require().Equal(expected.HTTPCode(), appErr.HTTPCode(), "unexpected error: %v", err)
+}
+
+// requireNotFound asserts err is domain.ErrUnknownResource (404)
+func (tsuite *UserRepositorySuite) requireNotFound(err error) {
+	tsuite.requireAppError(err, domain.ErrUnknownResource)
+	tsuite.Require().Equal(http.StatusNotFound, err.(*domain.AppError).HTTPCode())
+}
+
+// requireBadParameters asserts err is domain.ErrBadParameters (400)
+func (tsuite *UserRepositorySuite) requireBadParameters(err error) {
+	tsuite.requireAppError(err, domain.ErrBadParameters)
+	tsuite.Require().Equal(http.StatusBadRequest, err.(*domain.AppError).HTTPCode())
+}
+
+// requireDuplicate asserts err is domain.ErrBadParameters
+// detailing that value of field is already taken
+func (tsuite *UserRepositorySuite) requireDuplicate(err error, field string) {
+	tsuite.requireBadParameters(err)
+	details := err.(*domain.AppError).Details
+	tsuite.Require().Len(details, 1, "unexpected error: %v", err)
+	tsuite.Require().Equal(field, details[0].Field)
+	tsuite.Require().Equal(domain.DetailDuplicate, details[0].Code)
+}
+
+// insertUser inserts user numbered n with unique email and username
+func (tsuite *UserRepositorySuite) insertUser(n int, name string) domain.User {
+	user, err := tsuite.Repository.InsertOne(newUser(n, name))
+	tsuite.Require().NoError(err)
+	tsuite.Require().NotZero(user.ID)
+	return user
+}
+
+func newUser(n int, name string) domain.User {
+	return domain.User{
+		Email:         "user" + string(rune('a'+n)) + "@example.com",
+		Username:      "user" + string(rune('a'+n)),
+		Name:          name,
+		ProfileImgURL: "/img/user" + string(rune('a'+n)),
+		Location:      "Singapore",
+		Description:   "AboutMe...",
+	}
+}
+
+func (tsuite *UserRepositorySuite) TestShouldInsertAndGetUser() {
+	user := tsuite.insertUser(0, "Name-UserZero")
+
+	byID, err := tsuite.Repository.GetByID(user.ID)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(user.Email, byID.Email)
+	tsuite.Require().Equal(user.Username, byID.Username)
+	tsuite.Require().Equal(user.Name, byID.Name)
+	tsuite.Require().Zero(byID.FollowersCount)
+	tsuite.Require().Zero(byID.FollowingCount)
+
+	byEmail, err := tsuite.Repository.GetByEmail(user.Email)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(user.ID, byEmail.ID)
+
+	byUsername, err := tsuite.Repository.GetByUsername(user.Username)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(user.ID, byUsername.ID)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldNotGetUnknownUser() {
+	_, err := tsuite.Repository.GetByID(404)
+	tsuite.requireNotFound(err)
+
+	_, err = tsuite.Repository.GetByEmail("unknown@example.com")
+	tsuite.requireNotFound(err)
+
+	_, err = tsuite.Repository.GetByUsername("unknown")
+	tsuite.requireNotFound(err)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldNotInsertDuplicateEmail() {
+	user := tsuite.insertUser(0, "Name-UserZero")
+
+	duplicate := newUser(1, "Name-UserOne")
+	duplicate.Email = user.Email
+	_, err := tsuite.Repository.InsertOne(duplicate)
+	tsuite.requireDuplicate(err, "email")
+}
+
+func (tsuite *UserRepositorySuite) TestShouldNotInsertDuplicateUsername() {
+	user := tsuite.insertUser(0, "Name-UserZero")
+
+	duplicate := newUser(1, "Name-UserOne")
+	duplicate.Username = user.Username
+	_, err := tsuite.Repository.InsertOne(duplicate)
+	tsuite.requireDuplicate(err, "username")
+}
+
+func (tsuite *UserRepositorySuite) TestShouldUpdateUser() {
+	user := tsuite.insertUser(0, "Name-UserZero")
+
+	result, err := tsuite.Repository.UpdateOne(user.ID, domain.User{Username: "renamed", Location: "Jakarta"})
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(domain.User{Username: "renamed", Location: "Jakarta", UpdatedAt: result.UpdatedAt}, result,
+		"only updated fields are returned")
+	tsuite.Require().False(result.UpdatedAt.IsZero())
+
+	updated, err := tsuite.Repository.GetByID(user.ID)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal("renamed", updated.Username)
+	tsuite.Require().Equal("Jakarta", updated.Location)
+	tsuite.Require().Equal(user.Name, updated.Name)
+
+	_, err = tsuite.Repository.GetByUsername(user.Username)
+	tsuite.requireNotFound(err)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldDeleteUser() {
+	user := tsuite.insertUser(0, "Name-UserZero")
+
+	tsuite.Require().NoError(tsuite.Repository.DeleteOne(user.ID))
+
+	_, err := tsuite.Repository.GetByID(user.ID)
+	tsuite.requireNotFound(err)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldRelateAndUnrelateUsers() {
+	follower := tsuite.insertUser(0, "Name-UserZero")
+	followed := tsuite.insertUser(1, "Name-UserOne")
+
+	tsuite.Require().NoError(tsuite.Repository.RelateUsers(followed.ID, follower.ID))
+	tsuite.requireCounters(followed.ID, 1, 0)
+	tsuite.requireCounters(follower.ID, 0, 1)
+
+	followers, err := tsuite.Repository.ListFollowers(followed.ID, 1, 0)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(followers, 1)
+	tsuite.Require().Equal(follower.ID, followers[0].ID)
+
+	following, err := tsuite.Repository.ListFollowing(follower.ID, 1, 0)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(following, 1)
+	tsuite.Require().Equal(followed.ID, following[0].ID)
+
+	tsuite.Require().NoError(tsuite.Repository.UnrelateUsers(followed.ID, follower.ID))
+	tsuite.requireCounters(followed.ID, 0, 0)
+	tsuite.requireCounters(follower.ID, 0, 0)
+
+	followers, err = tsuite.Repository.ListFollowers(followed.ID, 1, 0)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Empty(followers)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldNotRelateUsersTwice() {
+	follower := tsuite.insertUser(0, "Name-UserZero")
+	followed := tsuite.insertUser(1, "Name-UserOne")
+
+	tsuite.Require().NoError(tsuite.Repository.RelateUsers(followed.ID, follower.ID))
+	err := tsuite.Repository.RelateUsers(followed.ID, follower.ID)
+	tsuite.requireDuplicate(err, "username")
+	tsuite.requireCounters(followed.ID, 1, 0)
+	tsuite.requireCounters(follower.ID, 0, 1)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldNotUnrelateUnrelatedUsers() {
+	follower := tsuite.insertUser(0, "Name-UserZero")
+	followed := tsuite.insertUser(1, "Name-UserOne")
+
+	err := tsuite.Repository.UnrelateUsers(followed.ID, follower.ID)
+	tsuite.requireNotFound(err)
+	tsuite.requireCounters(followed.ID, 0, 0)
+	tsuite.requireCounters(follower.ID, 0, 0)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldFetchManyUsers() {
+	tsuite.insertUser(0, "Alice")
+	tsuite.insertUser(1, "Alicia")
+	tsuite.insertUser(2, "Bob")
+
+	users, meta, err := tsuite.Repository.FetchMany(domain.User{Name: "Ali"}, 1, 1)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(users, 1)
+	tsuite.Require().Equal(domain.Metadata{Total: 2, Page: 1, Limit: 1, NextCursor: "2"}, meta)
+
+	users, meta, err = tsuite.Repository.FetchMany(domain.User{Name: "Ali"}, 2, 1)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(users, 1)
+	tsuite.Require().Empty(meta.NextCursor)
+
+	users, _, err = tsuite.Repository.FetchMany(domain.User{Username: newUser(2, "").Username}, 1, 0)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(users, 1)
+	tsuite.Require().Equal("Bob", users[0].Name)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldNotInsertTooLongFields() {
+	tooLong := []func(user *domain.User){
+		func(user *domain.User) { user.Email = strings.Repeat("e", 41) },
+		func(user *domain.User) { user.Username = strings.Repeat("u", 41) },
+		func(user *domain.User) { user.Name = strings.Repeat("n", 41) },
+		func(user *domain.User) { user.ProfileImgURL = strings.Repeat("p", 129) },
+		func(user *domain.User) { user.Location = strings.Repeat("l", 41) },
+		func(user *domain.User) { user.Description = strings.Repeat("d", 257) },
+	}
+
+	for i, setTooLong := range tooLong {
+		user := newUser(i, "Name-User")
+		setTooLong(&user)
+
+		_, err := tsuite.Repository.InsertOne(user)
+		tsuite.requireBadParameters(err)
+	}
+
+	_, meta, err := tsuite.Repository.FetchMany(domain.User{}, 1, 0)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Zero(meta.Total, "no user should be inserted")
+}
+
+func (tsuite *UserRepositorySuite) TestShouldNotUpdateTooLongFields() {
+	user := tsuite.insertUser(0, "Name-UserZero")
+
+	_, err := tsuite.Repository.UpdateOne(user.ID, domain.User{Name: strings.Repeat("n", 41)})
+	tsuite.requireBadParameters(err)
+
+	current, err := tsuite.Repository.GetByID(user.ID)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(user.Name, current.Name)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldNotUpdateToTakenUsername() {
+	user := tsuite.insertUser(0, "Name-UserZero")
+	other := tsuite.insertUser(1, "Name-UserOne")
+
+	_, err := tsuite.Repository.UpdateOne(user.ID, domain.User{Username: other.Username})
+	tsuite.requireDuplicate(err, "username")
+
+	current, err := tsuite.Repository.GetByUsername(other.Username)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(other.ID, current.ID)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldNotUpdateUnknownUser() {
+	_, err := tsuite.Repository.UpdateOne(404, domain.User{Name: "Name-Unknown"})
+	tsuite.requireNotFound(err)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldNotDeleteUserTwice() {
+	user := tsuite.insertUser(0, "Name-UserZero")
+
+	tsuite.Require().NoError(tsuite.Repository.DeleteOne(user.ID))
+	err := tsuite.Repository.DeleteOne(user.ID)
+	tsuite.requireNotFound(err)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldKeepDeletedUserReserved() {
+	user := tsuite.insertUser(0, "Name-UserZero")
+	tsuite.Require().NoError(tsuite.Repository.DeleteOne(user.ID))
+
+	_, err := tsuite.Repository.GetByUsername(user.Username)
+	tsuite.requireNotFound(err)
+	_, err = tsuite.Repository.GetByEmail(user.Email)
+	tsuite.requireNotFound(err)
+	users, _, err := tsuite.Repository.FetchMany(domain.User{}, 1, 0)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Empty(users)
+
+	_, err = tsuite.Repository.InsertOne(newUser(0, "Name-Taker"))
+	tsuite.Require().Error(err, "username and email must stay reserved until purged")
+	_, err = tsuite.Repository.UpdateOne(user.ID, domain.User{Name: "Name-Deleted"})
+	tsuite.requireNotFound(err)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldRestoreDeletedUser() {
+	user := tsuite.insertUser(0, "Name-UserZero")
+	tsuite.Require().NoError(tsuite.Repository.DeleteOne(user.ID))
+
+	restored, err := tsuite.Repository.RestoreOne(user.ID, time.Now().Add(-time.Hour))
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(user.Username, restored.Username)
+
+	found, err := tsuite.Repository.GetByUsername(user.Username)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(user.ID, found.ID)
+
+	_, err = tsuite.Repository.RestoreOne(user.ID, time.Now().Add(-time.Hour))
+	tsuite.requireNotFound(err)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldNotRestoreAfterGracePeriod() {
+	user := tsuite.insertUser(0, "Name-UserZero")
+	tsuite.Require().NoError(tsuite.Repository.DeleteOne(user.ID))
+
+	_, err := tsuite.Repository.RestoreOne(user.ID, time.Now().Add(time.Hour))
+	tsuite.requireNotFound(err)
+
+	deleted, err := tsuite.Repository.ListDeleted(time.Now().Add(time.Hour), 0)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(deleted, 1)
+	tsuite.Require().Equal(user.ID, deleted[0].ID)
+
+	deleted, err = tsuite.Repository.ListDeleted(time.Now().Add(time.Hour), 100)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(deleted, 1, "limit beyond page size is allowed")
+
+	deleted, err = tsuite.Repository.ListDeleted(time.Now().Add(-time.Hour), 0)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Empty(deleted)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldPurgeDeletedUser() {
+	user := tsuite.insertUser(0, "Name-UserZero")
+	follower := tsuite.insertUser(1, "Name-UserOne")
+	followed := tsuite.insertUser(2, "Name-UserTwo")
+	tsuite.Require().NoError(tsuite.Repository.RelateUsers(user.ID, follower.ID))
+	tsuite.Require().NoError(tsuite.Repository.RelateUsers(followed.ID, user.ID))
+
+	err := tsuite.Repository.PurgeOne(user.ID)
+	tsuite.requireNotFound(err)
+
+	tsuite.Require().NoError(tsuite.Repository.DeleteOne(user.ID))
+	tsuite.Require().NoError(tsuite.Repository.PurgeOne(user.ID))
+	tsuite.requireCounters(follower.ID, 0, 0)
+	tsuite.requireCounters(followed.ID, 0, 0)
+
+	following, err := tsuite.Repository.ListFollowing(follower.ID, 1, 0)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Empty(following)
+
+	_, err = tsuite.Repository.RestoreOne(user.ID, time.Now().Add(-time.Hour))
+	tsuite.requireNotFound(err)
+	_, err = tsuite.Repository.InsertOne(newUser(0, "Name-Taker"))
+	tsuite.Require().NoError(err, "username and email must be released once purged")
+}
+
+func (tsuite *UserRepositorySuite) TestShouldNotRelateUnknownUser() {
+	user := tsuite.insertUser(0, "Name-UserZero")
+
+	err := tsuite.Repository.RelateUsers(404, user.ID)
+	tsuite.requireNotFound(err)
+	tsuite.requireCounters(user.ID, 0, 0)
+
+	err = tsuite.Repository.RelateUsers(user.ID, 404)
+	tsuite.requireNotFound(err)
+	tsuite.requireCounters(user.ID, 0, 0)
+
+	followers, err := tsuite.Repository.ListFollowers(user.ID, 1, 0)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Empty(followers, "relationship must be rolled back")
+}
+
+func (tsuite *UserRepositorySuite) TestShouldPaginateFollowers() {
+	followed := tsuite.insertUser(0, "Name-UserZero")
+	for i := 1; i <= 3; i++ {
+		follower := tsuite.insertUser(i, "Name-Follower")
+		tsuite.Require().NoError(tsuite.Repository.RelateUsers(followed.ID, follower.ID))
+	}
+
+	firstPage, err := tsuite.Repository.ListFollowers(followed.ID, 1, 2)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(firstPage, 2)
+
+	lastPage, err := tsuite.Repository.ListFollowers(followed.ID, 2, 2)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Len(lastPage, 1)
+	tsuite.Require().True(firstPage[1].ID < lastPage[0].ID, "followers must be ordered by id")
+
+	beyond, err := tsuite.Repository.ListFollowers(followed.ID, 3, 2)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Empty(beyond)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldFetchNoUser() {
+	users, meta, err := tsuite.Repository.FetchMany(domain.User{Location: "Atlantis"}, 1, 0)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Empty(users)
+	tsuite.Require().Equal(domain.Metadata{Total: 0, Page: 1, Limit: 20}, meta)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldAbortCancelledContext() {
+	user := tsuite.insertUser(0, "Name-UserZero")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := tsuite.Repository.GetByIDContext(ctx, user.ID)
+	tsuite.requireAppError(err, domain.ErrRequestCancelled)
+
+	_, err = tsuite.Repository.InsertOneContext(ctx, newUser(1, "Name-UserOne"))
+	tsuite.requireAppError(err, domain.ErrRequestCancelled)
+
+	err = tsuite.Repository.DeleteOneContext(ctx, user.ID)
+	tsuite.requireAppError(err, domain.ErrRequestCancelled)
+
+	_, meta, err := tsuite.Repository.FetchMany(domain.User{}, 1, 0)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(1, meta.Total, "cancelled writes must not be applied")
+}
+
+func (tsuite *UserRepositorySuite) TestShouldAbortExpiredContext() {
+	user := tsuite.insertUser(0, "Name-UserZero")
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := tsuite.Repository.GetByUsernameContext(ctx, user.Username)
+	tsuite.requireAppError(err, domain.ErrRequestTimeout)
+
+	err = tsuite.Repository.RelateUsersContext(ctx, user.ID, user.ID)
+	tsuite.requireAppError(err, domain.ErrRequestTimeout)
+	tsuite.requireCounters(user.ID, 0, 0)
+}
+
+func (tsuite *UserRepositorySuite) TestShouldQueryWithLiveContext() {
+	user := tsuite.insertUser(0, "Name-UserZero")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	found, err := tsuite.Repository.GetByEmailContext(ctx, user.Email)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(user.ID, found.ID)
+}
+
+// requireCounters asserts followers and following count of user
+func (tsuite *UserRepositorySuite) requireCounters(userID uint64, followers int, following int) {
+	user, err := tsuite.Repository.GetByID(userID)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(followers, user.FollowersCount, "followers count")
+	tsuite.Require().Equal(following, user.FollowingCount, "following count")
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	// import built-in libraries
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/lib/publicid"
+	repocommon "github.com/iqdf/golumn-story-service/lib/repository"
+)
+
+// exportPageSize is number of rows queried at once while
+// exporting, not above DefaultLimit of any repository
+const exportPageSize = 20
+
+// exportInfo describes the export itself
+type exportInfo struct {
+	UserID     publicid.ID         `json:"user_id"`
+	Format     domain.ExportFormat `json:"format"`
+	ExportedAt time.Time           `json:"exported_at"`
+}
+
+// exportSection is part of user data export, written as field
+// of the json document or as json file of the zip archive
+type exportSection struct {
+	name  string
+	write func(ctx context.Context, jw *jsonWriter) error
+}
+
+// ExportUserData writes everything stored about user with given id:
+// profile, followers, following, and stories with their revisions.
+// Lists are streamed page by page, never held in memory. Once writing
+// has started, failure leaves w with truncated archive.
+func (service *UserService) ExportUserData(userID uint64, format domain.ExportFormat, w io.Writer) error {
+	return service.ExportUserDataContext(context.Background(), userID, format, w)
+}
+
+// ExportUserDataContext is ExportUserData propagating ctx to repository queries
+func (service *UserService) ExportUserDataContext(ctx context.Context, userID uint64, format domain.ExportFormat, w io.Writer) error {
+	if format != domain.ExportFormatJSON && format != domain.ExportFormatZip {
+		return domain.ErrBadParameters.WithFieldMessagef("format", domain.DetailInvalid, "unsupported export format %q", format)
+	}
+
+	// nothing is written for unknown user
+	user, err := service.userRepo.GetByIDContext(ctx, userID)
+	if err != nil {
+		return err
+	}
+	user.IsMe = true
+	user.GetURL()
+
+	info := exportInfo{UserID: publicid.ID(userID), Format: format, ExportedAt: time.Now().UTC()}
+	sections := []exportSection{
+		{"export", func(_ context.Context, jw *jsonWriter) error { jw.Value(info); return nil }},
+		{"user", func(_ context.Context, jw *jsonWriter) error { jw.Value(user); return nil }},
+		{"followers", func(ctx context.Context, jw *jsonWriter) error {
+			return service.exportFollowership(ctx, jw, userID, service.userRepo.ListFollowersContext)
+		}},
+		{"following", func(ctx context.Context, jw *jsonWriter) error {
+			return service.exportFollowership(ctx, jw, userID, service.userRepo.ListFollowingContext)
+		}},
+		{"stories", func(ctx context.Context, jw *jsonWriter) error {
+			return service.exportStories(ctx, jw, userID)
+		}},
+	}
+
+	if format == domain.ExportFormatZip {
+		err = writeExportZip(ctx, w, sections)
+	} else {
+		err = writeExportJSON(ctx, w, sections)
+	}
+	if _, ok := domain.AsAppError(err); err != nil && !ok {
+		return domain.ErrInternalServer.Wrap(err, "userservice: write user data export fail")
+	}
+	return err
+}
+
+func writeExportJSON(ctx context.Context, w io.Writer, sections []exportSection) error {
+	jw := &jsonWriter{w: w}
+	jw.Begin('{')
+	for _, section := range sections {
+		jw.Key(section.name)
+		if err := section.write(ctx, jw); err != nil {
+			return err
+		}
+	}
+	jw.End('}')
+	return jw.Err()
+}
+
+func writeExportZip(ctx context.Context, w io.Writer, sections []exportSection) error {
+	zw := zip.NewWriter(w)
+	for _, section := range sections {
+		file, err := zw.Create(section.name + ".json")
+		if err != nil {
+			return err
+		}
+		jw := &jsonWriter{w: file}
+		if err := section.write(ctx, jw); err != nil {
+			return err
+		}
+		if err := jw.Err(); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// listUsers queries page of users related to user with given id
+type listUsers func(ctx context.Context, userID uint64, page int, limit int) ([]domain.User, error)
+
+// exportFollowership writes array of users listed page by page,
+// only their public profile as they are other users' data
+func (service *UserService) exportFollowership(ctx context.Context, jw *jsonWriter, userID uint64, list listUsers) error {
+	jw.Begin('[')
+	for page := 1; ; page++ {
+		users, err := list(ctx, userID, page, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			user.ID, user.Email = 0, "" // owner only fields
+			user.GetURL()
+			jw.Value(user)
+		}
+		if len(users) < exportPageSize || jw.Err() != nil {
+			break
+		}
+	}
+	jw.End(']')
+	return nil
+}
+
+// exportStories writes array of stories authored by user,
+// each as {"story": ..., "revisions": [...]}
+func (service *UserService) exportStories(ctx context.Context, jw *jsonWriter, userID uint64) error {
+	jw.Begin('[')
+	for page := 1; service.storyRepo != nil; page++ {
+		// story repositories do not take ctx, check it between pages
+		if err := repocommon.ContextAppError(ctx, "userservice: export stories fail"); err != nil {
+			return err
+		}
+		stories, meta, err := service.storyRepo.FetchByAuthor(userID, page, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, story := range stories {
+			jw.Begin('{')
+			jw.Key("story")
+			jw.Value(story)
+			jw.Key("revisions")
+			if err := service.exportRevisions(ctx, jw, story.ID); err != nil {
+				return err
+			}
+			jw.End('}')
+		}
+		if len(meta.NextCursor) == 0 || jw.Err() != nil {
+			break
+		}
+	}
+	jw.End(']')
+	return nil
+}
+
+func (service *UserService) exportRevisions(ctx context.Context, jw *jsonWriter, storyID uint64) error {
+	jw.Begin('[')
+	for page := 1; service.revisionRepo != nil; page++ {
+		if err := repocommon.ContextAppError(ctx, "userservice: export revisions fail"); err != nil {
+			return err
+		}
+		revisions, meta, err := service.revisionRepo.ListRevisions(storyID, page, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, revision := range revisions {
+			jw.Value(revision)
+		}
+		if len(meta.NextCursor) == 0 || jw.Err() != nil {
+			break
+		}
+	}
+	jw.End(']')
+	return nil
+}
+
+// jsonWriter writes json value piece by piece, so that long arrays
+// are streamed rather than marshalled at once. Values are separated
+// by comma as needed. Write error is kept and reported by Err.
+type jsonWriter struct {
+	w   io.Writer
+	err error
+
+	counts   []int // number of values in each open array/object
+	afterKey bool  // next value is the value of a key
+}
+
+// Begin opens array '[' or object '{'
+func (jw *jsonWriter) Begin(delim byte) {
+	jw.separate()
+	jw.write([]byte{delim})
+	jw.counts = append(jw.counts, 0)
+}
+
+// End closes array ']' or object '}'
+func (jw *jsonWriter) End(delim byte) {
+	jw.counts = jw.counts[:len(jw.counts)-1]
+	jw.write([]byte{delim})
+}
+
+// Key writes key of object, the next value is its value
+func (jw *jsonWriter) Key(name string) {
+	jw.Value(name)
+	jw.write([]byte{':'})
+	jw.afterKey = true
+}
+
+// Value writes v marshalled as json
+func (jw *jsonWriter) Value(v interface{}) {
+	jw.separate()
+	if jw.err != nil {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		jw.err = err
+		return
+	}
+	jw.write(data)
+}
+
+// Err returns the first error writing json
+func (jw *jsonWriter) Err() error {
+	return jw.err
+}
+
+func (jw *jsonWriter) separate() {
+	if jw.afterKey {
+		jw.afterKey = false
+		return
+	}
+	if n := len(jw.counts); n > 0 {
+		if jw.counts[n-1] > 0 {
+			jw.write([]byte{','})
+		}
+		jw.counts[n-1]++
+	}
+}
+
+func (jw *jsonWriter) write(data []byte) {
+	if jw.err == nil {
+		_, jw.err = jw.w.Write(data)
+	}
+}
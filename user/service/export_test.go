@@ -0,0 +1,132 @@
+package service
+
+import (
+	// import built-in libraries
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	// import third-party libraries
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/domain/mocks"
+	"github.com/iqdf/golumn-story-service/user/repository/memory"
+)
+
+// exportDocument is the json document exported by ExportUserData
+type exportDocument struct {
+	Export    exportInfo    `json:"export"`
+	User      domain.User   `json:"user"`
+	Followers []domain.User `json:"followers"`
+	Following []domain.User `json:"following"`
+	Stories   []struct {
+		Story     domain.Story      `json:"story"`
+		Revisions []json.RawMessage `json:"revisions"`
+	} `json:"stories"`
+}
+
+// newExportService creates service with author followed by n
+// users, who wrote one story of two revisions
+func newExportService(t *testing.T, n int) (*UserService, domain.User) {
+	userRepo := memory.NewUserMemoryRepository(nil)
+	author, err := userRepo.InsertOne(domain.User{Email: "author@example.com", Username: "author", Name: "Author"})
+	require.NoError(t, err)
+	for i := 0; i < n; i++ {
+		follower, err := userRepo.InsertOne(domain.User{
+			Email:    fmt.Sprintf("follower%d@example.com", i),
+			Username: fmt.Sprintf("follower%d", i),
+			Name:     "Follower",
+		})
+		require.NoError(t, err)
+		require.NoError(t, userRepo.RelateUsers(author.ID, follower.ID))
+	}
+
+	story := domain.Story{ID: 71, AuthorID: author.ID, Title: "Hello, Golumn!"}
+	storyRepo := new(mocks.StoryRepository)
+	storyRepo.On("FetchByAuthor", author.ID, 1, exportPageSize).
+		Return([]domain.Story{story}, domain.Metadata{Total: 1, Page: 1, Limit: exportPageSize}, nil)
+
+	revisions := []domain.StoryRevision{{StoryID: story.ID, Number: 2}, {StoryID: story.ID, Number: 1}}
+	revisionRepo := new(mocks.StoryRevisionRepository)
+	revisionRepo.On("ListRevisions", story.ID, 1, exportPageSize).
+		Return(revisions, domain.Metadata{Total: 2, Page: 1, Limit: exportPageSize}, nil)
+
+	service := NewUserService(userRepo)
+	service.SetStoryRepositories(storyRepo, revisionRepo)
+	return service, author
+}
+
+func TestExportUserDataJSON(t *testing.T) {
+	service, author := newExportService(t, exportPageSize+1)
+
+	var buf bytes.Buffer
+	require.NoError(t, service.ExportUserData(author.ID, domain.ExportFormatJSON, &buf))
+
+	var document exportDocument
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &document), buf.String())
+	require.Equal(t, author.ID, uint64(document.Export.UserID))
+	require.Equal(t, author.Email, document.User.Email)
+	require.False(t, document.User.CreatedAt.IsZero())
+	require.Len(t, document.Followers, exportPageSize+1, "every page of followers must be exported")
+	require.Empty(t, document.Followers[0].Email, "followers are exported with public profile only")
+	require.NotNil(t, document.Following)
+	require.Empty(t, document.Following)
+	require.Len(t, document.Stories, 1)
+	require.Len(t, document.Stories[0].Revisions, 2)
+}
+
+func TestExportUserDataZip(t *testing.T) {
+	service, author := newExportService(t, 1)
+
+	var buf bytes.Buffer
+	require.NoError(t, service.ExportUserData(author.ID, domain.ExportFormatZip, &buf))
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(archive.File))
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	require.Equal(t, []string{"export.json", "user.json", "followers.json", "following.json", "stories.json"}, names)
+
+	file, err := archive.File[1].Open()
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(file)
+	require.NoError(t, err)
+
+	var user domain.User
+	require.NoError(t, json.Unmarshal(data, &user))
+	require.Equal(t, author.Username, user.Username)
+}
+
+func TestExportUserDataWithoutStories(t *testing.T) {
+	userRepo := memory.NewUserMemoryRepository(nil)
+	author, err := userRepo.InsertOne(domain.User{Email: "author@example.com", Username: "author", Name: "Author"})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, NewUserService(userRepo).ExportUserData(author.ID, domain.ExportFormatJSON, &buf))
+	require.Contains(t, buf.String(), `"stories":[]`)
+}
+
+func TestNotExportUnknownUser(t *testing.T) {
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("GetByIDContext", mock.Anything, mockUser.ID).Return(domain.User{}, errNotFound).Once()
+
+	var buf bytes.Buffer
+	err := NewUserService(userRepo).ExportUserData(mockUser.ID, domain.ExportFormatZip, &buf)
+	require.True(t, errors.Is(err, domain.ErrUnknownResource))
+	require.Zero(t, buf.Len(), "nothing must be written for unknown user")
+
+	err = NewUserService(userRepo).ExportUserData(mockUser.ID, "csv", &buf)
+	require.True(t, errors.Is(err, domain.ErrBadParameters))
+	require.Zero(t, buf.Len())
+}
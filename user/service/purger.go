@@ -0,0 +1,129 @@
+package service
+
+import (
+	// import built-in libraries
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+)
+
+// PurgeBatchSize is number of deleted users
+// listed at once by UserPurger.PurgeExpired
+const PurgeBatchSize = 20
+
+// UserPurger hard deletes user accounts soft deleted longer than
+// the grace period ago, along with their stories and revisions
+type UserPurger struct {
+	userRepo    domain.UserRepository
+	uow         domain.UnitOfWork
+	gracePeriod time.Duration
+	logger      *log.Logger
+	now         func() time.Time
+}
+
+// NewUserPurger creates new UserPurger. Each user is purged within
+// single transaction of uow, so that stories of purged user are never
+// left behind. Nil uow purges the user account only. Nil logger
+// logs to stderr.
+func NewUserPurger(userRepo domain.UserRepository, uow domain.UnitOfWork, gracePeriod time.Duration, logger *log.Logger) *UserPurger {
+	if logger == nil {
+		logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	return &UserPurger{
+		userRepo:    userRepo,
+		uow:         uow,
+		gracePeriod: gracePeriod,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// PurgeExpired purges every user deleted before the grace period,
+// returns number of purged users. Failure to purge a user is logged
+// and skipped, so that it never blocks purge of the other users, and
+// is returned once they are purged, wrapping the first failure.
+func (purger *UserPurger) PurgeExpired(ctx context.Context) (int, error) {
+	deletedBefore := purger.now().Add(-purger.gracePeriod)
+
+	var (
+		purged   = 0
+		failed   = make(map[uint64]bool)
+		firstErr error
+	)
+	for {
+		// failed users are listed again, oldest first, list past them
+		users, err := purger.userRepo.ListDeletedContext(ctx, deletedBefore, PurgeBatchSize+len(failed))
+		if err != nil {
+			return purged, err
+		}
+
+		attempted := false
+		for _, user := range users {
+			if failed[user.ID] {
+				continue
+			}
+			attempted = true
+			if err := purger.purgeUser(ctx, user.ID); err != nil {
+				if ctx.Err() != nil {
+					return purged, err
+				}
+				purger.logger.Printf("userpurger: user=%d error=%q", user.ID, err.Error())
+				failed[user.ID] = true
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			purged++
+		}
+
+		if !attempted {
+			if firstErr != nil {
+				return purged, fmt.Errorf("userpurger: %d users failed to purge, first: %w", len(failed), firstErr)
+			}
+			return purged, nil
+		}
+	}
+}
+
+func (purger *UserPurger) purgeUser(ctx context.Context, userID uint64) error {
+	if purger.uow == nil {
+		return purger.userRepo.PurgeOneContext(ctx, userID)
+	}
+	return purger.uow.Do(ctx, func(repos domain.Repositories) error {
+		// revisions reference stories, delete them first
+		if err := repos.Revisions.DeleteByAuthor(userID); err != nil {
+			return err
+		}
+		if err := repos.Stories.DeleteByAuthor(userID); err != nil {
+			return err
+		}
+		return repos.Users.PurgeOneContext(ctx, userID)
+	})
+}
+
+// Run purges expired users every interval until ctx is done
+func (purger *UserPurger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := purger.PurgeExpired(ctx)
+		if err != nil {
+			purger.logger.Printf("userpurger: purged=%d error=%q", purged, err.Error())
+		} else if purged > 0 {
+			purger.logger.Printf("userpurger: purged=%d", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	// import built-in libraries
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	// import third-party libraries
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/domain/mocks"
+)
+
+func newTestPurger(repos domain.Repositories, now time.Time) (*UserPurger, *mocks.UnitOfWork) {
+	uow := new(mocks.UnitOfWork)
+	uow.On("Do", mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, fn func(domain.Repositories) error) error {
+			return fn(repos)
+		})
+
+	purger := NewUserPurger(repos.Users, uow, time.Hour, log.New(ioutil.Discard, "", 0))
+	purger.now = func() time.Time { return now }
+	return purger, uow
+}
+
+func TestPurgeExpiredUsers(t *testing.T) {
+	var (
+		now       = time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+		users     = new(mocks.UserRepository)
+		stories   = new(mocks.StoryRepository)
+		revisions = new(mocks.StoryRevisionRepository)
+	)
+	purger, uow := newTestPurger(domain.Repositories{Users: users, Stories: stories, Revisions: revisions}, now)
+
+	deletedBefore := now.Add(-time.Hour)
+	users.On("ListDeletedContext", mock.Anything, deletedBefore, PurgeBatchSize).
+		Return([]domain.User{mockUser, mockOtherUser}, nil).Once()
+	users.On("ListDeletedContext", mock.Anything, deletedBefore, PurgeBatchSize).
+		Return([]domain.User{}, nil).Once()
+	for _, user := range []domain.User{mockUser, mockOtherUser} {
+		revisions.On("DeleteByAuthor", user.ID).Return(nil).Once()
+		stories.On("DeleteByAuthor", user.ID).Return(nil).Once()
+		users.On("PurgeOneContext", mock.Anything, user.ID).Return(nil).Once()
+	}
+
+	purged, err := purger.PurgeExpired(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, purged)
+
+	uow.AssertNumberOfCalls(t, "Do", 2)
+	users.AssertExpectations(t)
+	stories.AssertExpectations(t)
+	revisions.AssertExpectations(t)
+}
+
+func TestPurgeExpiredSkipsFailure(t *testing.T) {
+	var (
+		users     = new(mocks.UserRepository)
+		stories   = new(mocks.StoryRepository)
+		revisions = new(mocks.StoryRevisionRepository)
+	)
+	purger, _ := newTestPurger(domain.Repositories{Users: users, Stories: stories, Revisions: revisions}, time.Now())
+
+	// failed user is listed again, past it there is no user left
+	users.On("ListDeletedContext", mock.Anything, mock.Anything, PurgeBatchSize).
+		Return([]domain.User{mockUser, mockOtherUser}, nil).Once()
+	users.On("ListDeletedContext", mock.Anything, mock.Anything, PurgeBatchSize+1).
+		Return([]domain.User{mockUser}, nil).Once()
+	revisions.On("DeleteByAuthor", mockUser.ID).Return(domain.ErrInternalServer).Once()
+	revisions.On("DeleteByAuthor", mockOtherUser.ID).Return(nil).Once()
+	stories.On("DeleteByAuthor", mockOtherUser.ID).Return(nil).Once()
+	users.On("PurgeOneContext", mock.Anything, mockOtherUser.ID).Return(nil).Once()
+
+	purged, err := purger.PurgeExpired(context.Background())
+	require.True(t, errors.Is(err, domain.ErrInternalServer))
+	require.Contains(t, err.Error(), "1 users failed to purge")
+	require.Equal(t, 1, purged, "user after the failed one is purged")
+
+	users.AssertExpectations(t)
+	stories.AssertExpectations(t)
+	revisions.AssertExpectations(t)
+	stories.AssertNotCalled(t, "DeleteByAuthor", mockUser.ID)
+}
+
+func TestRunPurgerUntilDone(t *testing.T) {
+	users := new(mocks.UserRepository)
+	purger, _ := newTestPurger(domain.Repositories{Users: users}, time.Now())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	users.On("ListDeletedContext", mock.Anything, mock.Anything, PurgeBatchSize).
+		Run(func(mock.Arguments) { cancel() }).
+		Return([]domain.User{}, nil)
+
+	done := make(chan struct{})
+	go func() {
+		purger.Run(ctx, time.Hour)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("purger must stop once ctx is done")
+	}
+}
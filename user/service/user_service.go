@@ -0,0 +1,380 @@
+package service
+
+import (
+	// import built-in libraries
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/lib/validation"
+)
+
+// DefaultGracePeriod is how long deleted user account
+// can be restored before it is purged for good
+const DefaultGracePeriod = 30 * 24 * time.Hour
+
+// UserService implements domain.UserService on top
+// of user-data persistence layer (domain.UserRepository)
+type UserService struct {
+	userRepo     domain.UserRepository
+	storyRepo    domain.StoryRepository
+	revisionRepo domain.StoryRevisionRepository
+	uow          domain.UnitOfWork
+	gracePeriod  time.Duration
+}
+
+// NewUserService creates new UserService
+func NewUserService(userRepo domain.UserRepository) *UserService {
+	return &UserService{userRepo: userRepo, gracePeriod: DefaultGracePeriod}
+}
+
+// NewUserServiceWithUnitOfWork creates new UserService whose
+// use-cases reading then writing users run within single
+// transaction of uow, e.g. follow user and delete account
+func NewUserServiceWithUnitOfWork(userRepo domain.UserRepository, uow domain.UnitOfWork) *UserService {
+	return &UserService{userRepo: userRepo, uow: uow, gracePeriod: DefaultGracePeriod}
+}
+
+// SetGracePeriod changes how long deleted user account can be
+// restored, it must match grace period of the UserPurger
+func (service *UserService) SetGracePeriod(gracePeriod time.Duration) {
+	service.gracePeriod = gracePeriod
+}
+
+// SetStoryRepositories enables export of stories authored by user,
+// without them ExportUserData exports no authored content
+func (service *UserService) SetStoryRepositories(storyRepo domain.StoryRepository, revisionRepo domain.StoryRevisionRepository) {
+	service.storyRepo = storyRepo
+	service.revisionRepo = revisionRepo
+}
+
+// transaction runs fn with copy of service bound to single transaction
+// of unit of work, or with service itself if there is none
+func (service *UserService) transaction(ctx context.Context, fn func(txService *UserService) error) error {
+	if service.uow == nil {
+		return fn(service)
+	}
+	return service.uow.Do(ctx, func(repos domain.Repositories) error {
+		txService := *service
+		txService.userRepo = repos.Users
+		if service.storyRepo != nil {
+			txService.storyRepo, txService.revisionRepo = repos.Stories, repos.Revisions
+		}
+		return fn(&txService)
+	})
+}
+
+// isUnknownResource reports whether err is an app error
+// signaling that the requested resource does not exist
+func isUnknownResource(err error) bool {
+	return errors.Is(err, domain.ErrUnknownResource)
+}
+
+// isDuplicateOf reports whether err is an app error
+// signaling that value of field is already taken
+func isDuplicateOf(err error, field string) bool {
+	appErr, ok := domain.AsAppError(err)
+	if !ok || !errors.Is(appErr, domain.ErrBadParameters) {
+		return false
+	}
+	for _, detail := range appErr.Details {
+		if detail.Field == field && detail.Code == domain.DetailDuplicate {
+			return true
+		}
+	}
+	return false
+}
+
+// GetUserProfile returns public profile of user with given username
+func (service *UserService) GetUserProfile(username string) (domain.User, error) {
+	return service.GetUserProfileContext(context.Background(), username)
+}
+
+// GetUserProfileContext is GetUserProfile propagating ctx to repository queries
+func (service *UserService) GetUserProfileContext(ctx context.Context, username string) (domain.User, error) {
+	username = strings.TrimSpace(username)
+	if len(username) == 0 {
+		return domain.User{}, domain.ErrBadParameters.WithFieldMessagef("username", domain.DetailRequired, "username is required")
+	}
+
+	user, err := service.userRepo.GetByUsernameContext(ctx, username)
+	if err != nil {
+		return domain.User{}, err
+	}
+	user.GetURL()
+	return user, nil
+}
+
+// GetOrCreateUser returns user registered with given email.
+// If none is registered yet, creates new user with given email,
+// granted that fields of user are valid (see validation.ValidateUser).
+func (service *UserService) GetOrCreateUser(email string, user domain.User) (domain.User, error) {
+	return service.GetOrCreateUserContext(context.Background(), email, user)
+}
+
+// GetOrCreateUserContext is GetOrCreateUser propagating ctx to repository queries
+func (service *UserService) GetOrCreateUserContext(ctx context.Context, email string, user domain.User) (result domain.User, err error) {
+	email = strings.TrimSpace(email)
+	if err := validation.ValidateEmail(email); err != nil {
+		return domain.User{}, err
+	}
+
+	err = service.transaction(ctx, func(txService *UserService) (err error) {
+		result, err = txService.getOrCreateUser(ctx, email, user)
+		return
+	})
+	return
+}
+
+func (service *UserService) getOrCreateUser(ctx context.Context, email string, user domain.User) (domain.User, error) {
+	existing, err := service.userRepo.GetByEmailContext(ctx, email)
+	if err == nil {
+		existing.IsMe = true
+		existing.GetURL()
+		return existing, nil
+	}
+	if !isUnknownResource(err) {
+		return domain.User{}, err
+	}
+	return service.insertUser(ctx, email, user)
+}
+
+// CreateUser creates new user of user.Email, granted that fields of user
+// are valid. Unlike GetOrCreateUser, it never returns existing user,
+// registered email fails with duplicate email error instead.
+func (service *UserService) CreateUser(user domain.User) (domain.User, error) {
+	return service.CreateUserContext(context.Background(), user)
+}
+
+// CreateUserContext is CreateUser propagating ctx to repository queries
+func (service *UserService) CreateUserContext(ctx context.Context, user domain.User) (result domain.User, err error) {
+	email := strings.TrimSpace(user.Email)
+	if err := validation.ValidateEmail(email); err != nil {
+		return domain.User{}, err
+	}
+
+	err = service.transaction(ctx, func(txService *UserService) (err error) {
+		result, err = txService.createUser(ctx, email, user)
+		return
+	})
+	return
+}
+
+func (service *UserService) createUser(ctx context.Context, email string, user domain.User) (domain.User, error) {
+	_, err := service.userRepo.GetByEmailContext(ctx, email)
+	if err == nil {
+		return domain.User{}, domain.ErrBadParameters.WithFieldMessagef("email", domain.DetailDuplicate, "email %v is already registered", email)
+	}
+	if !isUnknownResource(err) {
+		return domain.User{}, err
+	}
+	return service.insertUser(ctx, email, user)
+}
+
+// insertUser creates new user of email, granted that fields
+// of user are valid and its username is not taken
+func (service *UserService) insertUser(ctx context.Context, email string, user domain.User) (domain.User, error) {
+	user.Email = email
+	if err := validation.ValidateUser(user); err != nil {
+		return domain.User{}, err
+	}
+	if err := service.checkUsernameAvailable(ctx, 0, user.Username); err != nil {
+		return domain.User{}, err
+	}
+
+	created, err := service.userRepo.InsertOneContext(ctx, user)
+	if isDuplicateOf(err, "email") {
+		// no user of email is found, hence it is reserved by deleted account
+		return domain.User{}, domain.ErrBadParameters.WithFieldMessagef("email", domain.DetailDeleted,
+			"account of email %v is deleted, restore it before it is purged", email)
+	}
+	if err != nil {
+		return domain.User{}, err
+	}
+	created.IsMe = true
+	created.GetURL()
+	return created, nil
+}
+
+// DeleteUser soft deletes user with given id, the account can be
+// restored with RestoreUser until purged after the grace period.
+// Followers/following counters of related users keep counting the
+// deleted user until purge, so that restore needs not recount them.
+func (service *UserService) DeleteUser(userID uint64) error {
+	return service.DeleteUserContext(context.Background(), userID)
+}
+
+// DeleteUserContext is DeleteUser propagating ctx to repository queries
+func (service *UserService) DeleteUserContext(ctx context.Context, userID uint64) error {
+	return service.transaction(ctx, func(txService *UserService) error {
+		return txService.deleteUser(ctx, userID)
+	})
+}
+
+func (service *UserService) deleteUser(ctx context.Context, userID uint64) error {
+	if _, err := service.userRepo.GetByIDContext(ctx, userID); err != nil {
+		return err
+	}
+	return service.userRepo.DeleteOneContext(ctx, userID)
+}
+
+// RestoreUser undoes deletion of user with given id, granted
+// that it was deleted within the grace period
+func (service *UserService) RestoreUser(userID uint64) (domain.User, error) {
+	return service.RestoreUserContext(context.Background(), userID)
+}
+
+// RestoreUserContext is RestoreUser propagating ctx to repository queries
+func (service *UserService) RestoreUserContext(ctx context.Context, userID uint64) (domain.User, error) {
+	deletedAfter := time.Now().Add(-service.gracePeriod)
+	restored, err := service.userRepo.RestoreOneContext(ctx, userID, deletedAfter)
+	if err != nil {
+		return domain.User{}, err
+	}
+	restored.IsMe = true
+	restored.GetURL()
+	return restored, nil
+}
+
+// UpdateUsername changes username of user with given id, granted
+// that the new username is valid and not taken by other user.
+func (service *UserService) UpdateUsername(userID uint64, user domain.User) (domain.User, error) {
+	return service.UpdateUsernameContext(context.Background(), userID, user)
+}
+
+// UpdateUsernameContext is UpdateUsername propagating ctx to repository queries
+func (service *UserService) UpdateUsernameContext(ctx context.Context, userID uint64, user domain.User) (result domain.User, err error) {
+	username := strings.TrimSpace(user.Username)
+	if err := validation.ValidateUsername(username); err != nil {
+		return domain.User{}, err
+	}
+
+	err = service.transaction(ctx, func(txService *UserService) (err error) {
+		result, err = txService.updateUsername(ctx, userID, username)
+		return
+	})
+	return
+}
+
+func (service *UserService) updateUsername(ctx context.Context, userID uint64, username string) (domain.User, error) {
+	current, err := service.userRepo.GetByIDContext(ctx, userID)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if current.Username == username {
+		current.IsMe = true
+		current.GetURL()
+		return current, nil
+	}
+
+	if err := service.checkUsernameAvailable(ctx, userID, username); err != nil {
+		return domain.User{}, err
+	}
+
+	// only username is updated, other fields are left untouched
+	if _, err := service.userRepo.UpdateOneContext(ctx, userID, domain.User{Username: username}); err != nil {
+		return domain.User{}, err
+	}
+
+	current.Username = username
+	current.URL = ""
+	current.IsMe = true
+	current.GetURL()
+	return current, nil
+}
+
+// FollowUser makes user with given id follows the user
+// with followedUsername. Returns the updated followed user.
+func (service *UserService) FollowUser(userID uint64, followedUsername string) (domain.User, error) {
+	return service.FollowUserContext(context.Background(), userID, followedUsername)
+}
+
+// FollowUserContext is FollowUser propagating ctx to repository queries
+func (service *UserService) FollowUserContext(ctx context.Context, userID uint64, followedUsername string) (result domain.User, err error) {
+	err = service.transaction(ctx, func(txService *UserService) (err error) {
+		result, err = txService.followUser(ctx, userID, followedUsername)
+		return
+	})
+	return
+}
+
+func (service *UserService) followUser(ctx context.Context, userID uint64, followedUsername string) (domain.User, error) {
+	follower, followed, err := service.getFollowPair(ctx, userID, followedUsername)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	if err := service.userRepo.RelateUsersContext(ctx, followed.ID, follower.ID); err != nil {
+		return domain.User{}, err
+	}
+	return service.GetUserProfileContext(ctx, followed.Username)
+}
+
+// UnfollowUser makes user with given id unfollows the user
+// with followedUsername. Returns the updated followed user.
+func (service *UserService) UnfollowUser(userID uint64, followedUsername string) (domain.User, error) {
+	return service.UnfollowUserContext(context.Background(), userID, followedUsername)
+}
+
+// UnfollowUserContext is UnfollowUser propagating ctx to repository queries
+func (service *UserService) UnfollowUserContext(ctx context.Context, userID uint64, followedUsername string) (result domain.User, err error) {
+	err = service.transaction(ctx, func(txService *UserService) (err error) {
+		result, err = txService.unfollowUser(ctx, userID, followedUsername)
+		return
+	})
+	return
+}
+
+func (service *UserService) unfollowUser(ctx context.Context, userID uint64, followedUsername string) (domain.User, error) {
+	follower, followed, err := service.getFollowPair(ctx, userID, followedUsername)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	if err := service.userRepo.UnrelateUsersContext(ctx, followed.ID, follower.ID); err != nil {
+		return domain.User{}, err
+	}
+	return service.GetUserProfileContext(ctx, followed.Username)
+}
+
+// getFollowPair fetches follower and followed users
+// and ensures that a user cannot follow him/herself
+func (service *UserService) getFollowPair(ctx context.Context, userID uint64, followedUsername string) (follower, followed domain.User, err error) {
+	follower, err = service.userRepo.GetByIDContext(ctx, userID)
+	if err != nil {
+		return
+	}
+
+	followed, err = service.GetUserProfileContext(ctx, followedUsername)
+	if err != nil {
+		return
+	}
+
+	if follower.ID == followed.ID {
+		err = domain.ErrBadParameters.WithFieldMessagef("username", domain.DetailInvalid, "user cannot follow him/herself")
+	}
+	return
+}
+
+// checkUsernameAvailable returns error when username
+// is already taken by user other than given userID
+func (service *UserService) checkUsernameAvailable(ctx context.Context, userID uint64, username string) error {
+	if len(username) == 0 {
+		return domain.ErrBadParameters.WithFieldMessagef("username", domain.DetailRequired, "username is required")
+	}
+
+	owner, err := service.userRepo.GetByUsernameContext(ctx, username)
+	if err == nil {
+		if owner.ID != userID {
+			return domain.ErrBadParameters.WithFieldMessagef("username", domain.DetailDuplicate, "username %v is already taken", username)
+		}
+		return nil
+	}
+	if isUnknownResource(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,343 @@
+package service
+
+import (
+	// import built-in libraries
+	"context"
+	"testing"
+	"time"
+
+	// import third-party libraries
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	// import our local packages
+	"github.com/iqdf/golumn-story-service/domain"
+	"github.com/iqdf/golumn-story-service/domain/mocks"
+	"github.com/iqdf/golumn-story-service/lib/validation"
+)
+
+type TestSuite struct {
+	suite.Suite
+	Repository *mocks.UserRepository
+	Service    domain.UserService
+}
+
+func (tsuite *TestSuite) SetupTest() {
+	tsuite.Repository = new(mocks.UserRepository)
+	tsuite.Service = NewUserService(tsuite.Repository)
+}
+
+func (tsuite *TestSuite) AfterTest(_, _ string) {
+	tsuite.Repository.AssertExpectations(tsuite.T())
+}
+
+func TestInit(t *testing.T) {
+	suite.Run(t, new(TestSuite))
+}
+
+// requireAppErrorCode asserts err is an *domain.AppError with given code
+func (tsuite *TestSuite) requireAppErrorCode(err error, code int) {
+	tsuite.Require().Error(err)
+	appErr, ok := err.(*domain.AppError)
+	tsuite.Require().True(ok, "expect *domain.AppError, got %T", err)
+	tsuite.Require().Equal(code, appErr.Code())
+}
+
+func (tsuite *TestSuite) requireDetails(err error, details []domain.ErrorDetail) {
+	tsuite.requireAppErrorCode(err, domain.InvalidParamCode)
+	tsuite.Require().Equal(details, err.(*domain.AppError).Details)
+}
+
+var mockUser = domain.User{
+	ID:       1,
+	Email:    "UserZero-email@example.com",
+	Username: "UserZero",
+	Name:     "Name-UserZero",
+}
+
+var mockOtherUser = domain.User{
+	ID:       2,
+	Email:    "UserOne-email@example.com",
+	Username: "UserOne",
+	Name:     "Name-UserOne",
+}
+
+var errNotFound = domain.ErrUnknownResource.WithMessage("not found")
+
+func (tsuite *TestSuite) TestShouldGetUserProfile() {
+	tsuite.Repository.On("GetByUsernameContext", mock.Anything, mockUser.Username).Return(mockUser, nil).Once()
+
+	user, err := tsuite.Service.GetUserProfile(mockUser.Username)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(mockUser.ID, user.ID)
+	tsuite.Require().Equal("/@"+mockUser.Username, user.URL)
+}
+
+func (tsuite *TestSuite) TestShouldNotGetUserProfileWithEmptyUsername() {
+	_, err := tsuite.Service.GetUserProfile(" ")
+	tsuite.requireAppErrorCode(err, domain.InvalidParamCode)
+}
+
+func (tsuite *TestSuite) TestShouldGetExistingUser() {
+	tsuite.Repository.On("GetByEmailContext", mock.Anything, mockUser.Email).Return(mockUser, nil).Once()
+
+	user, err := tsuite.Service.GetOrCreateUser(mockUser.Email, domain.User{})
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(mockUser.ID, user.ID)
+	tsuite.Require().True(user.IsMe)
+}
+
+func (tsuite *TestSuite) TestShouldCreateUser() {
+	newUser := domain.User{Username: mockUser.Username, Name: mockUser.Name}
+	insertUser := newUser
+	insertUser.Email = mockUser.Email
+
+	tsuite.Repository.On("GetByEmailContext", mock.Anything, mockUser.Email).Return(domain.User{}, errNotFound).Once()
+	tsuite.Repository.On("GetByUsernameContext", mock.Anything, mockUser.Username).Return(domain.User{}, errNotFound).Once()
+	tsuite.Repository.On("InsertOneContext", mock.Anything, insertUser).Return(mockUser, nil).Once()
+
+	user, err := tsuite.Service.GetOrCreateUser(mockUser.Email, newUser)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(mockUser.ID, user.ID)
+}
+
+func (tsuite *TestSuite) TestShouldNotCreateUserWithTakenUsername() {
+	newUser := domain.User{Username: mockOtherUser.Username, Name: mockUser.Name}
+
+	tsuite.Repository.On("GetByEmailContext", mock.Anything, mockUser.Email).Return(domain.User{}, errNotFound).Once()
+	tsuite.Repository.On("GetByUsernameContext", mock.Anything, mockOtherUser.Username).Return(mockOtherUser, nil).Once()
+
+	_, err := tsuite.Service.GetOrCreateUser(mockUser.Email, newUser)
+	tsuite.requireAppErrorCode(err, domain.InvalidParamCode)
+	tsuite.Repository.AssertNotCalled(tsuite.T(), "InsertOneContext", mock.Anything, mock.Anything)
+}
+
+func (tsuite *TestSuite) TestShouldCreateNewUser() {
+	newUser := domain.User{Email: mockUser.Email, Username: mockUser.Username, Name: mockUser.Name}
+
+	tsuite.Repository.On("GetByEmailContext", mock.Anything, mockUser.Email).Return(domain.User{}, errNotFound).Once()
+	tsuite.Repository.On("GetByUsernameContext", mock.Anything, mockUser.Username).Return(domain.User{}, errNotFound).Once()
+	tsuite.Repository.On("InsertOneContext", mock.Anything, newUser).Return(mockUser, nil).Once()
+
+	user, err := tsuite.Service.CreateUser(newUser)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(mockUser.ID, user.ID)
+	tsuite.Require().True(user.IsMe)
+}
+
+func (tsuite *TestSuite) TestShouldNotCreateUserWithRegisteredEmail() {
+	newUser := domain.User{Email: mockUser.Email, Username: "UserTwo", Name: "Name-UserTwo"}
+
+	tsuite.Repository.On("GetByEmailContext", mock.Anything, mockUser.Email).Return(mockUser, nil).Once()
+
+	user, err := tsuite.Service.CreateUser(newUser)
+	tsuite.requireDetails(err, []domain.ErrorDetail{
+		{Field: "email", Code: domain.DetailDuplicate, Message: "email " + mockUser.Email + " is already registered"},
+	})
+	tsuite.Require().Zero(user.ID, "existing user must not be returned")
+	tsuite.Repository.AssertNotCalled(tsuite.T(), "InsertOneContext", mock.Anything, mock.Anything)
+}
+
+func (tsuite *TestSuite) TestShouldNotCreateUserWithEmailOfDeletedUser() {
+	newUser := domain.User{Email: mockUser.Email, Username: mockUser.Username, Name: mockUser.Name}
+
+	tsuite.Repository.On("GetByEmailContext", mock.Anything, mockUser.Email).Return(domain.User{}, errNotFound).Once()
+	tsuite.Repository.On("GetByUsernameContext", mock.Anything, mockUser.Username).Return(domain.User{}, errNotFound).Once()
+	tsuite.Repository.On("InsertOneContext", mock.Anything, newUser).
+		Return(domain.User{}, domain.ErrBadParameters.WithFieldMessagef("email", domain.DetailDuplicate, "conflict duplicate email")).Once()
+
+	_, err := tsuite.Service.CreateUser(newUser)
+	tsuite.requireDetails(err, []domain.ErrorDetail{
+		{Field: "email", Code: domain.DetailDeleted, Message: "account of email " + mockUser.Email + " is deleted, restore it before it is purged"},
+	})
+}
+
+func (tsuite *TestSuite) TestShouldNotGetOrCreateUserWithInvalidEmail() {
+	_, err := tsuite.Service.GetOrCreateUser("not-an-email", domain.User{})
+	tsuite.requireDetails(err, []domain.ErrorDetail{
+		{Field: "email", Code: validation.RuleEmail, Message: "email must be a valid email address"},
+	})
+	tsuite.Repository.AssertNotCalled(tsuite.T(), "GetByEmailContext", mock.Anything, mock.Anything)
+}
+
+func (tsuite *TestSuite) TestShouldNotCreateInvalidUser() {
+	newUser := domain.User{Username: "abc", Name: ""}
+
+	tsuite.Repository.On("GetByEmailContext", mock.Anything, mockUser.Email).Return(domain.User{}, errNotFound).Once()
+
+	_, err := tsuite.Service.GetOrCreateUser(mockUser.Email, newUser)
+	tsuite.requireDetails(err, []domain.ErrorDetail{
+		{Field: "username", Code: validation.RuleMinLength, Message: "username must be at least 5 characters"},
+		{Field: "name", Code: validation.RuleRequired, Message: "name is required"},
+	})
+	tsuite.Repository.AssertNotCalled(tsuite.T(), "GetByUsernameContext", mock.Anything, mock.Anything)
+	tsuite.Repository.AssertNotCalled(tsuite.T(), "InsertOneContext", mock.Anything, mock.Anything)
+}
+
+func (tsuite *TestSuite) TestShouldDeleteUser() {
+	tsuite.Repository.On("GetByIDContext", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+	tsuite.Repository.On("DeleteOneContext", mock.Anything, mockUser.ID).Return(nil).Once()
+
+	err := tsuite.Service.DeleteUser(mockUser.ID)
+	tsuite.Require().NoError(err)
+}
+
+func (tsuite *TestSuite) TestShouldNotDeleteUnknownUser() {
+	tsuite.Repository.On("GetByIDContext", mock.Anything, mockUser.ID).Return(domain.User{}, errNotFound).Once()
+
+	err := tsuite.Service.DeleteUser(mockUser.ID)
+	tsuite.requireAppErrorCode(err, domain.UnknownResourceCode)
+	tsuite.Repository.AssertNotCalled(tsuite.T(), "DeleteOneContext", mock.Anything, mock.Anything)
+}
+
+func (tsuite *TestSuite) TestShouldRestoreUserWithinGracePeriod() {
+	withinGracePeriod := mock.MatchedBy(func(deletedAfter time.Time) bool {
+		deadline := time.Now().Add(-DefaultGracePeriod)
+		return deletedAfter.Sub(deadline) < time.Minute && deadline.Sub(deletedAfter) < time.Minute
+	})
+	tsuite.Repository.On("RestoreOneContext", mock.Anything, mockUser.ID, withinGracePeriod).Return(mockUser, nil).Once()
+
+	user, err := tsuite.Service.RestoreUser(mockUser.ID)
+	tsuite.Require().NoError(err)
+	tsuite.Require().True(user.IsMe)
+	tsuite.Require().Equal(mockUser.ID, user.ID)
+}
+
+func (tsuite *TestSuite) TestShouldNotRestoreExpiredUser() {
+	tsuite.Repository.On("RestoreOneContext", mock.Anything, mockUser.ID, mock.Anything).Return(domain.User{}, errNotFound).Once()
+
+	_, err := tsuite.Service.RestoreUser(mockUser.ID)
+	tsuite.requireAppErrorCode(err, domain.UnknownResourceCode)
+}
+
+func (tsuite *TestSuite) TestShouldUpdateUsername() {
+	newUsername := "UserZeroRenamed"
+
+	tsuite.Repository.On("GetByIDContext", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+	tsuite.Repository.On("GetByUsernameContext", mock.Anything, newUsername).Return(domain.User{}, errNotFound).Once()
+	tsuite.Repository.On("UpdateOneContext", mock.Anything, mockUser.ID, domain.User{Username: newUsername}).
+		Return(domain.User{Username: newUsername}, nil).Once()
+
+	user, err := tsuite.Service.UpdateUsername(mockUser.ID, domain.User{Username: newUsername})
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(newUsername, user.Username)
+	tsuite.Require().Equal("/@"+newUsername, user.URL)
+}
+
+func (tsuite *TestSuite) TestShouldNotUpdateTakenUsername() {
+	tsuite.Repository.On("GetByIDContext", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+	tsuite.Repository.On("GetByUsernameContext", mock.Anything, mockOtherUser.Username).Return(mockOtherUser, nil).Once()
+
+	_, err := tsuite.Service.UpdateUsername(mockUser.ID, domain.User{Username: mockOtherUser.Username})
+	tsuite.requireAppErrorCode(err, domain.InvalidParamCode)
+	tsuite.Repository.AssertNotCalled(tsuite.T(), "UpdateOneContext", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (tsuite *TestSuite) TestShouldNotUpdateInvalidUsername() {
+	_, err := tsuite.Service.UpdateUsername(mockUser.ID, domain.User{Username: "User Zero"})
+	tsuite.requireDetails(err, []domain.ErrorDetail{{
+		Field:   "username",
+		Code:    validation.RulePattern,
+		Message: "username must contain only letters, digits, '.', '_' or '-'",
+	}})
+	tsuite.Repository.AssertNotCalled(tsuite.T(), "GetByIDContext", mock.Anything, mock.Anything)
+}
+
+func (tsuite *TestSuite) TestShouldFollowUser() {
+	followed := mockOtherUser
+	followed.FollowersCount = 1
+
+	tsuite.Repository.On("GetByIDContext", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+	tsuite.Repository.On("GetByUsernameContext", mock.Anything, mockOtherUser.Username).Return(mockOtherUser, nil).Once()
+	tsuite.Repository.On("RelateUsersContext", mock.Anything, mockOtherUser.ID, mockUser.ID).Return(nil).Once()
+	tsuite.Repository.On("GetByUsernameContext", mock.Anything, mockOtherUser.Username).Return(followed, nil).Once()
+
+	user, err := tsuite.Service.FollowUser(mockUser.ID, mockOtherUser.Username)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(1, user.FollowersCount)
+}
+
+func (tsuite *TestSuite) TestShouldNotFollowSelf() {
+	tsuite.Repository.On("GetByIDContext", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+	tsuite.Repository.On("GetByUsernameContext", mock.Anything, mockUser.Username).Return(mockUser, nil).Once()
+
+	_, err := tsuite.Service.FollowUser(mockUser.ID, mockUser.Username)
+	tsuite.requireAppErrorCode(err, domain.InvalidParamCode)
+	tsuite.Repository.AssertNotCalled(tsuite.T(), "RelateUsersContext", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (tsuite *TestSuite) TestShouldUnfollowUser() {
+	tsuite.Repository.On("GetByIDContext", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+	tsuite.Repository.On("GetByUsernameContext", mock.Anything, mockOtherUser.Username).Return(mockOtherUser, nil).Twice()
+	tsuite.Repository.On("UnrelateUsersContext", mock.Anything, mockOtherUser.ID, mockUser.ID).Return(nil).Once()
+
+	user, err := tsuite.Service.UnfollowUser(mockUser.ID, mockOtherUser.Username)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(mockOtherUser.ID, user.ID)
+}
+
+func (tsuite *TestSuite) TestShouldPropagateContext() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tsuite.Repository.On("GetByUsernameContext", ctx, mockUser.Username).Return(mockUser, nil).Once()
+
+	user, err := tsuite.Service.GetUserProfileContext(ctx, mockUser.Username)
+	tsuite.Require().NoError(err)
+	tsuite.Require().Equal(mockUser.ID, user.ID)
+}
+
+func (tsuite *TestSuite) TestShouldAbortCancelledFollow() {
+	errCancelled := domain.ErrRequestCancelled.Wrap(context.Canceled, "cancelled")
+
+	tsuite.Repository.On("GetByIDContext", mock.Anything, mockUser.ID).Return(domain.User{}, errCancelled).Once()
+
+	_, err := tsuite.Service.FollowUserContext(context.Background(), mockUser.ID, mockOtherUser.Username)
+	tsuite.requireAppErrorCode(err, domain.RequestCancelledCode)
+	tsuite.Repository.AssertNotCalled(tsuite.T(), "RelateUsersContext", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (tsuite *TestSuite) TestShouldFollowUserInUnitOfWork() {
+	txRepository := new(mocks.UserRepository)
+	uow := new(mocks.UnitOfWork)
+	uow.On("Do", mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, fn func(domain.Repositories) error) error {
+			return fn(domain.Repositories{Users: txRepository})
+		}).Once()
+
+	txRepository.On("GetByIDContext", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+	txRepository.On("GetByUsernameContext", mock.Anything, mockOtherUser.Username).Return(mockOtherUser, nil).Twice()
+	txRepository.On("RelateUsersContext", mock.Anything, mockOtherUser.ID, mockUser.ID).Return(nil).Once()
+
+	service := NewUserServiceWithUnitOfWork(tsuite.Repository, uow)
+	_, err := service.FollowUser(mockUser.ID, mockOtherUser.Username)
+	tsuite.Require().NoError(err)
+
+	// every query runs on transaction bound repository
+	uow.AssertExpectations(tsuite.T())
+	txRepository.AssertExpectations(tsuite.T())
+}
+
+func (tsuite *TestSuite) TestShouldKeepSettingsInTransaction() {
+	txRepository := new(mocks.UserRepository)
+	txStories, txRevisions := new(mocks.StoryRepository), new(mocks.StoryRevisionRepository)
+	uow := new(mocks.UnitOfWork)
+	uow.On("Do", mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, fn func(domain.Repositories) error) error {
+			return fn(domain.Repositories{Users: txRepository, Stories: txStories, Revisions: txRevisions})
+		}).Once()
+
+	service := NewUserServiceWithUnitOfWork(tsuite.Repository, uow)
+	service.SetGracePeriod(time.Hour)
+	service.SetStoryRepositories(new(mocks.StoryRepository), new(mocks.StoryRevisionRepository))
+
+	err := service.transaction(context.Background(), func(txService *UserService) error {
+		tsuite.Require().Equal(time.Hour, txService.gracePeriod)
+		tsuite.Require().Same(uow, txService.uow)
+		tsuite.Require().Same(txRepository, txService.userRepo)
+		tsuite.Require().Same(txStories, txService.storyRepo)
+		tsuite.Require().Same(txRevisions, txService.revisionRepo)
+		return nil
+	})
+	tsuite.Require().NoError(err)
+}